@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"github.com/Sreejit-Sengupto/utils/response"
+)
+
+var verdictStatuses = []string{"APPROVED", "REJECTED", "FLAGGED", "PENDING"}
+
+type StatusMetrics struct {
+	// Precision and Recall are null when there is nothing to divide by
+	Precision *float64 `json:"precision"`
+	Recall    *float64 `json:"recall"`
+	Predicted int64    `json:"predicted"`
+	Actual    int64    `json:"actual"`
+}
+
+// AgreementMetrics compares the model's verdicts with the reviewer's.
+// The confusion matrix is indexed model status -> reviewer status.
+type AgreementMetrics struct {
+	Reviewed        int64                       `json:"reviewed"`
+	Agreed          int64                       `json:"agreed"`
+	OverrideRate    *float64                    `json:"overrideRate"`
+	ConfusionMatrix map[string]map[string]int64 `json:"confusionMatrix"`
+	PerStatus       map[string]StatusMetrics    `json:"perStatus"`
+}
+
+type AgreementPoint struct {
+	Label        string                   `json:"label"`
+	Reviewed     int64                    `json:"reviewed"`
+	OverrideRate *float64                 `json:"overrideRate"`
+	PerStatus    map[string]StatusMetrics `json:"perStatus"`
+}
+
+type ModelAgreement struct {
+	Model         string `json:"model"`
+	PolicyVersion string `json:"policyVersion"`
+	AgreementMetrics
+	Series []AgreementPoint `json:"series"`
+}
+
+type AgreementReport struct {
+	Overall     AgreementMetrics            `json:"overall"`
+	ByMediaType map[string]AgreementMetrics `json:"byMediaType"`
+	ByModel     []ModelAgreement            `json:"byModel"`
+	Labels      []string                    `json:"labels"`
+	Range       timerange.Range             `json:"range"`
+}
+
+// confusion accumulates (model status, reviewer status) pairs
+type confusion map[string]map[string]int64
+
+func (c confusion) add(model, human string, n int64) {
+	if c[model] == nil {
+		c[model] = make(map[string]int64)
+	}
+	c[model][human] += n
+}
+
+func (c confusion) metrics() AgreementMetrics {
+	m := AgreementMetrics{
+		ConfusionMatrix: make(map[string]map[string]int64),
+		PerStatus:       make(map[string]StatusMetrics),
+	}
+
+	predicted := make(map[string]int64)
+	actual := make(map[string]int64)
+	for _, model := range verdictStatuses {
+		m.ConfusionMatrix[model] = make(map[string]int64)
+		for _, human := range verdictStatuses {
+			n := c[model][human]
+			m.ConfusionMatrix[model][human] = n
+			m.Reviewed += n
+			predicted[model] += n
+			actual[human] += n
+			if model == human {
+				m.Agreed += n
+			}
+		}
+	}
+
+	m.OverrideRate = ratio(m.Reviewed-m.Agreed, m.Reviewed)
+	for _, s := range verdictStatuses {
+		m.PerStatus[s] = StatusMetrics{
+			Precision: ratio(c[s][s], predicted[s]),
+			Recall:    ratio(c[s][s], actual[s]),
+			Predicted: predicted[s],
+			Actual:    actual[s],
+		}
+	}
+	return m
+}
+
+func ratio(num, den int64) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := float64(num) / float64(den)
+	return &v
+}
+
+// GetModelAgreement compares each per-modality model verdict with the
+// reviewer's verdict for the same modality on the content's latest audit
+func (h *Handler) GetModelAgreement(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, timeSeriesSpan)
+	if !ok {
+		return
+	}
+
+	mediaTypes, err := parseEnumList(r.URL.Query().Get("mediaType"), "mediaType", "TXT", "IMG", "VID")
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pairs, err := h.repos.Analytics.AgreementPairs(r.Context(), rng, mediaTypes)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to compute model agreement")
+		return
+	}
+
+	type modelKey struct{ model, policyVersion string }
+
+	overall := confusion{}
+	byMediaType := make(map[string]confusion)
+	byModel := make(map[modelKey]confusion)
+	byModelBucket := make(map[modelKey]map[string]confusion)
+
+	for _, p := range pairs {
+		key := modelKey{p.Model, p.PolicyVersion}
+		label := rng.Label(p.Bucket)
+
+		overall.add(p.ModelStatus, p.HumanStatus, p.Count)
+
+		if byMediaType[p.MediaType] == nil {
+			byMediaType[p.MediaType] = confusion{}
+		}
+		byMediaType[p.MediaType].add(p.ModelStatus, p.HumanStatus, p.Count)
+
+		if byModel[key] == nil {
+			byModel[key] = confusion{}
+			byModelBucket[key] = make(map[string]confusion)
+		}
+		byModel[key].add(p.ModelStatus, p.HumanStatus, p.Count)
+
+		if byModelBucket[key][label] == nil {
+			byModelBucket[key][label] = confusion{}
+		}
+		byModelBucket[key][label].add(p.ModelStatus, p.HumanStatus, p.Count)
+	}
+
+	labels := []string{}
+	for _, b := range rng.Buckets() {
+		labels = append(labels, rng.Label(b))
+	}
+
+	report := AgreementReport{
+		Overall:     overall.metrics(),
+		ByMediaType: make(map[string]AgreementMetrics),
+		ByModel:     []ModelAgreement{},
+		Labels:      labels,
+		Range:       rng,
+	}
+	for mediaType, c := range byMediaType {
+		report.ByMediaType[mediaType] = c.metrics()
+	}
+	for key, c := range byModel {
+		ma := ModelAgreement{
+			Model:            key.model,
+			PolicyVersion:    key.policyVersion,
+			AgreementMetrics: c.metrics(),
+		}
+		for _, label := range labels {
+			m := byModelBucket[key][label].metrics()
+			ma.Series = append(ma.Series, AgreementPoint{
+				Label:        label,
+				Reviewed:     m.Reviewed,
+				OverrideRate: m.OverrideRate,
+				PerStatus:    m.PerStatus,
+			})
+		}
+		report.ByModel = append(report.ByModel, ma)
+	}
+	sort.Slice(report.ByModel, func(i, j int) bool {
+		if report.ByModel[i].Model != report.ByModel[j].Model {
+			return report.ByModel[i].Model < report.ByModel[j].Model
+		}
+		return report.ByModel[i].PolicyVersion < report.ByModel[j].PolicyVersion
+	})
+
+	response.JSON(w, http.StatusOK, report)
+}
@@ -1,32 +1,41 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/Sreejit-Sengupto/internal/database"
 	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/timerange"
 	"github.com/Sreejit-Sengupto/utils/response"
 )
 
-type StatusCount struct {
-	Label string `json:"label"`
-	Value int64  `json:"value"`
-}
+// Default windows used when the caller passes neither from nor to
+const (
+	timeSeriesSpan = 30 * 24 * time.Hour
+	summarySpan    = 7 * 24 * time.Hour
+)
 
-type MediaTypeCount struct {
-	Label string `json:"label"`
-	Value int64  `json:"value"`
-}
+const (
+	defaultHistogramBuckets = 10
+	maxHistogramBuckets     = 100
+)
 
 type RiskScoreRange struct {
-	Range string `json:"range"`
-	Count int64  `json:"count"`
+	Range string  `json:"range"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
 }
 
 type TimeSeriesData struct {
 	Labels   []string         `json:"labels"`
 	Datasets []DatasetEntry   `json:"datasets"`
+	Range    *timerange.Range `json:"range,omitempty"`
 }
 
 type DatasetEntry struct {
@@ -43,73 +52,80 @@ type RadarDataPoint struct {
 }
 
 type ModerationSummary struct {
-	TotalContent     int64 `json:"totalContent"`
-	PendingCount     int64 `json:"pendingCount"`
-	ApprovedCount    int64 `json:"approvedCount"`
-	RejectedCount    int64 `json:"rejectedCount"`
-	FlaggedCount     int64 `json:"flaggedCount"`
-	TotalAudits      int64 `json:"totalAudits"`
-	AvgRiskScore     float64 `json:"avgRiskScore"`
-	ContentLastWeek  int64 `json:"contentLastWeek"`
+	TotalContent    int64           `json:"totalContent"`
+	PendingCount    int64           `json:"pendingCount"`
+	ApprovedCount   int64           `json:"approvedCount"`
+	RejectedCount   int64           `json:"rejectedCount"`
+	FlaggedCount    int64           `json:"flaggedCount"`
+	TotalAudits     int64           `json:"totalAudits"`
+	AvgRiskScore    float64         `json:"avgRiskScore"`
+	ContentInRange  int64           `json:"contentInRange"`
+	ContentLastWeek int64           `json:"contentLastWeek"` // contentInRange's old name, kept for existing clients
+	Range           timerange.Range `json:"range"`
+}
+
+// parseAnalyticsRange reads the shared from/to/granularity/tz parameters and
+// answers 400 when they don't make sense
+func parseAnalyticsRange(w http.ResponseWriter, r *http.Request, defaultSpan time.Duration) (timerange.Range, bool) {
+	rng, err := timerange.Parse(r.URL.Query(), defaultSpan)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return rng, false
+	}
+	return rng, true
 }
 
-func GetStatusDistribution(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
+func (h *Handler) GetStatusDistribution(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, 0)
+	if !ok {
+		return
+	}
 
-	var results []StatusCount
-	db.Model(&models.Content{}).
-		Select("final_status as label, COUNT(*) as value").
-		Group("final_status").
-		Scan(&results)
+	results, err := h.repos.Analytics.FinalStatusCounts(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count content by status")
+		return
+	}
 
 	response.JSON(w, http.StatusOK, map[string]interface{}{
 		"data": results,
 	})
 }
 
-func GetModerationOverTime(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
-
-	type DailyCount struct {
-		Date   string `json:"date"`
-		Status string `json:"status"`
-		Count  int64  `json:"count"`
+func (h *Handler) GetModerationOverTime(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, timeSeriesSpan)
+	if !ok {
+		return
 	}
 
-	var dailyCounts []DailyCount
-	db.Model(&models.Content{}).
-		Select("DATE(created_at) as date, final_status as status, COUNT(*) as count").
-		Where("created_at >= ?", time.Now().AddDate(0, 0, -30)).
-		Group("DATE(created_at), final_status").
-		Order("date").
-		Scan(&dailyCounts)
-
-	dateMap := make(map[string]map[string]int64)
-	var labels []string
-	labelSet := make(map[string]bool)
+	bucketCounts, err := h.repos.Analytics.FinalStatusOverTime(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count content over time")
+		return
+	}
 
-	for _, dc := range dailyCounts {
-		if !labelSet[dc.Date] {
-			labels = append(labels, dc.Date)
-			labelSet[dc.Date] = true
-		}
-		if dateMap[dc.Date] == nil {
-			dateMap[dc.Date] = make(map[string]int64)
+	bucketMap := make(map[string]map[string]int64)
+	for _, bc := range bucketCounts {
+		label := rng.Label(bc.Bucket)
+		if bucketMap[label] == nil {
+			bucketMap[label] = make(map[string]int64)
 		}
-		dateMap[dc.Date][dc.Status] = dc.Count
+		bucketMap[label][bc.Status] = bc.Count
+	}
+
+	// Every bucket in the range is listed, with zeros where nothing happened
+	labels := []string{}
+	for _, b := range rng.Buckets() {
+		labels = append(labels, rng.Label(b))
 	}
 
 	statuses := []string{"APPROVED", "REJECTED", "FLAGGED", "PENDING"}
 	var datasets []DatasetEntry
 
 	for _, status := range statuses {
-		var data []int64
-		for _, label := range labels {
-			if val, ok := dateMap[label][status]; ok {
-				data = append(data, val)
-			} else {
-				data = append(data, 0)
-			}
+		data := make([]int64, len(labels))
+		for i, label := range labels {
+			data[i] = bucketMap[label][status]
 		}
 		datasets = append(datasets, DatasetEntry{
 			Label: status,
@@ -120,48 +136,81 @@ func GetModerationOverTime(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, TimeSeriesData{
 		Labels:   labels,
 		Datasets: datasets,
+		Range:    &rng,
 	})
 }
 
-func GetMediaTypeBreakdown(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
+func (h *Handler) GetMediaTypeBreakdown(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, 0)
+	if !ok {
+		return
+	}
 
-	var results []MediaTypeCount
-	db.Model(&models.ModerationResult{}).
-		Select("media_type as label, COUNT(*) as value").
-		Group("media_type").
-		Scan(&results)
+	results, err := h.repos.Analytics.ResultMediaTypeCounts(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count results by media type")
+		return
+	}
 
 	response.JSON(w, http.StatusOK, map[string]interface{}{
 		"data": results,
 	})
 }
 
-func GetRiskScoreDistribution(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
+// GetRiskScoreDistribution returns a histogram of moderation result risk
+// scores, which the model reports on a 0-1 scale. Buckets are half-open
+// [min, max) except the last, which includes its upper edge.
+func (h *Handler) GetRiskScoreDistribution(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, 0)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
 
-	var results []RiskScoreRange
+	edges, err := parseHistogramEdges(query.Get("buckets"), query.Get("edges"))
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	ranges := []struct {
-		Min   float64
-		Max   float64
-		Label string
-	}{
-		{0, 25, "0-25"},
-		{25, 50, "26-50"},
-		{50, 75, "51-75"},
-		{75, 100, "76-100"},
+	mediaTypes, err := parseEnumList(query.Get("mediaType"), "mediaType", "TXT", "IMG", "VID")
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	statuses, err := parseEnumList(query.Get("status"), "status", "PENDING", "APPROVED", "REJECTED", "FLAGGED")
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	categoryNames := make([]string, len(models.Categories))
+	for i, c := range models.Categories {
+		categoryNames[i] = string(c)
+	}
+	categories, err := parseEnumList(query.Get("category"), "category", categoryNames...)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	for _, rng := range ranges {
-		var count int64
-		db.Model(&models.ModerationResult{}).
-			Where("risk_score >= ? AND risk_score <= ?", rng.Min, rng.Max).
-			Count(&count)
+	counts, err := h.repos.Analytics.RiskHistogram(r.Context(), rng, edges, repository.ResultFilter{
+		MediaTypes: mediaTypes,
+		Statuses:   statuses,
+		Categories: categories,
+	})
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to compute risk score histogram")
+		return
+	}
 
+	results := make([]RiskScoreRange, 0, len(edges)-1)
+	for i := 0; i < len(edges)-1; i++ {
 		results = append(results, RiskScoreRange{
-			Range: rng.Label,
-			Count: count,
+			Range: fmt.Sprintf("%s-%s", formatEdge(edges[i]), formatEdge(edges[i+1])),
+			Min:   edges[i],
+			Max:   edges[i+1],
+			Count: counts[i],
 		})
 	}
 
@@ -170,20 +219,79 @@ func GetRiskScoreDistribution(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func GetStatusByMediaType(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
+// parseHistogramEdges turns either an explicit, strictly increasing list of
+// edges or an even split of [0, 1] into bucket edges
+func parseHistogramEdges(bucketsStr, edgesStr string) ([]float64, error) {
+	if bucketsStr != "" && edgesStr != "" {
+		return nil, fmt.Errorf("pass either buckets or edges, not both")
+	}
 
-	type MediaStatusCount struct {
-		MediaType string `json:"mediaType"`
-		Status    string `json:"status"`
-		Count     int64  `json:"count"`
+	if edgesStr != "" {
+		parts := strings.Split(edgesStr, ",")
+		if len(parts) < 2 || len(parts) > maxHistogramBuckets+1 {
+			return nil, fmt.Errorf("edges must list between 2 and %d values", maxHistogramBuckets+1)
+		}
+		edges := make([]float64, len(parts))
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid edge %q", p)
+			}
+			if i > 0 && v <= edges[i-1] {
+				return nil, fmt.Errorf("edges must be strictly increasing")
+			}
+			edges[i] = v
+		}
+		return edges, nil
+	}
+
+	buckets := defaultHistogramBuckets
+	if bucketsStr != "" {
+		n, err := strconv.Atoi(bucketsStr)
+		if err != nil || n < 1 || n > maxHistogramBuckets {
+			return nil, fmt.Errorf("buckets must be between 1 and %d", maxHistogramBuckets)
+		}
+		buckets = n
+	}
+
+	edges := make([]float64, buckets+1)
+	for i := range edges {
+		edges[i] = float64(i) / float64(buckets)
 	}
+	return edges, nil
+}
 
-	var counts []MediaStatusCount
-	db.Model(&models.ModerationResult{}).
-		Select("media_type, status, COUNT(*) as count").
-		Group("media_type, status").
-		Scan(&counts)
+// parseEnumList parses a comma-separated list, rejecting values outside allowed
+func parseEnumList(raw, name string, allowed ...string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if !slices.Contains(allowed, v) {
+			return nil, fmt.Errorf("invalid %s %q", name, v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func formatEdge(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (h *Handler) GetStatusByMediaType(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, 0)
+	if !ok {
+		return
+	}
+
+	counts, err := h.repos.Analytics.ResultStatusByMediaType(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count results by media type and status")
+		return
+	}
 
 	mediaMap := make(map[string]*RadarDataPoint)
 	for _, c := range counts {
@@ -212,28 +320,30 @@ func GetStatusByMediaType(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func GetAuditActivity(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
+func (h *Handler) GetAuditActivity(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, timeSeriesSpan)
+	if !ok {
+		return
+	}
 
-	type DailyAudit struct {
-		Date  string `json:"date"`
-		Count int64  `json:"count"`
+	bucketAudits, err := h.repos.Analytics.AuditsOverTime(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count audits over time")
+		return
 	}
 
-	var dailyAudits []DailyAudit
-	db.Model(&models.Audit{}).
-		Select("DATE(created_at) as date, COUNT(*) as count").
-		Where("created_at >= ?", time.Now().AddDate(0, 0, -30)).
-		Group("DATE(created_at)").
-		Order("date").
-		Scan(&dailyAudits)
+	countMap := make(map[string]int64)
+	for _, ba := range bucketAudits {
+		countMap[rng.Label(ba.Bucket)] = ba.Count
+	}
 
-	var labels []string
-	var data []int64
+	labels := []string{}
+	data := []int64{}
 
-	for _, da := range dailyAudits {
-		labels = append(labels, da.Date)
-		data = append(data, da.Count)
+	for _, b := range rng.Buckets() {
+		label := rng.Label(b)
+		labels = append(labels, label)
+		data = append(data, countMap[label])
 	}
 
 	response.JSON(w, http.StatusOK, TimeSeriesData{
@@ -241,30 +351,50 @@ func GetAuditActivity(w http.ResponseWriter, r *http.Request) {
 		Datasets: []DatasetEntry{
 			{Label: "Audits", Data: data},
 		},
+		Range: &rng,
 	})
 }
 
-func GetModerationSummary(w http.ResponseWriter, r *http.Request) {
-	db := database.DB
-
-	var summary ModerationSummary
+func (h *Handler) GetModerationSummary(w http.ResponseWriter, r *http.Request) {
+	// Without a window the totals are all-time and only contentInRange
+	// defaults to the last week
+	rng, ok := parseAnalyticsRange(w, r, 0)
+	if !ok {
+		return
+	}
 
-	db.Model(&models.Content{}).Count(&summary.TotalContent)
-	db.Model(&models.Content{}).Where("final_status = ?", "PENDING").Count(&summary.PendingCount)
-	db.Model(&models.Content{}).Where("final_status = ?", "APPROVED").Count(&summary.ApprovedCount)
-	db.Model(&models.Content{}).Where("final_status = ?", "REJECTED").Count(&summary.RejectedCount)
-	db.Model(&models.Content{}).Where("final_status = ?", "FLAGGED").Count(&summary.FlaggedCount)
-	db.Model(&models.Audit{}).Count(&summary.TotalAudits)
+	counts, err := h.repos.Analytics.Summary(r.Context(), rng)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to summarize moderation")
+		return
+	}
 
-	var avgScore struct {
-		Avg float64
+	inRange := counts.TotalContent
+	if rng.From.IsZero() && rng.To.IsZero() {
+		recent, ok := parseAnalyticsRange(w, r, summarySpan)
+		if !ok {
+			return
+		}
+		recentCounts, err := h.repos.Analytics.Summary(r.Context(), recent)
+		if err != nil {
+			response.JSONError(w, http.StatusInternalServerError, "Failed to summarize moderation")
+			return
+		}
+		inRange = recentCounts.TotalContent
 	}
-	db.Model(&models.ModerationResult{}).Select("COALESCE(AVG(risk_score), 0) as avg").Scan(&avgScore)
-	summary.AvgRiskScore = avgScore.Avg
 
-	db.Model(&models.Content{}).
-		Where("created_at >= ?", time.Now().AddDate(0, 0, -7)).
-		Count(&summary.ContentLastWeek)
+	summary := ModerationSummary{
+		TotalContent:    counts.TotalContent,
+		PendingCount:    counts.PendingCount,
+		ApprovedCount:   counts.ApprovedCount,
+		RejectedCount:   counts.RejectedCount,
+		FlaggedCount:    counts.FlaggedCount,
+		TotalAudits:     counts.TotalAudits,
+		AvgRiskScore:    counts.AvgRiskScore,
+		ContentInRange:  inRange,
+		ContentLastWeek: inRange,
+		Range:           rng,
+	}
 
 	response.JSON(w, http.StatusOK, summary)
 }
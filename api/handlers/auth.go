@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+)
+
+// Authenticate scopes every request to the tenant of its API key when keys
+// are registered, answering 401 without a registered key and 403 when
+// X-Tenant-ID names another tenant. Without registered keys requests keep
+// naming their tenant in X-Tenant-ID.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.opts.APIKeys) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID, ok := h.opts.APIKeys[r.Header.Get(tenant.KeyHeader)]
+		if !ok {
+			response.JSONError(w, http.StatusUnauthorized, "A registered "+tenant.KeyHeader+" is required")
+			return
+		}
+		if named := r.Header.Get(tenant.Header); named != "" && named != tenantID {
+			response.JSONError(w, http.StatusForbidden, tenant.Header+" must be the tenant of the API key")
+			return
+		}
+		next.ServeHTTP(w, tenant.Authenticate(r, tenantID))
+	})
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/moderation"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/internal/tracing"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/Sreejit-Sengupto/utils/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxBatchItems caps how many items a single batch request may carry
+	maxBatchItems = 1000
+	// maxNDJSONLine caps the size of one NDJSON line
+	maxNDJSONLine = 1 << 20
+)
+
+var errTooManyItems = fmt.Errorf("Batch exceeds the limit of %d items", maxBatchItems)
+
+type batchItem struct {
+	Text     string `json:"text" validate:"required"`
+	Image    string `json:"image" validate:"omitempty,url"`
+	Video    string `json:"video" validate:"omitempty,url"`
+	AuthorID string `json:"authorId"`
+}
+
+type BatchItemResult struct {
+	Index int        `json:"index"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	BatchID  uuid.UUID         `json:"batchId"`
+	Total    int               `json:"total"`
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Items    []BatchItemResult `json:"items"`
+}
+
+type BatchProgress struct {
+	BatchID   uuid.UUID        `json:"batchId"`
+	Total     int              `json:"total"`
+	Accepted  int              `json:"accepted"`
+	Rejected  int              `json:"rejected"`
+	Completed int64            `json:"completed"`
+	Pending   int64            `json:"pending"`
+	Statuses  map[string]int64 `json:"statuses"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func (h *Handler) UploadBatch(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(tracing.FromRequest(r), "UploadBatch")
+	defer span.End()
+
+	var (
+		items []batchItem
+		err   error
+	)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-ndjson" {
+		items, err = decodeNDJSON(r.Body)
+	} else {
+		items, err = decodeJSONBatch(r.Body)
+	}
+	if errors.Is(err, errTooManyItems) {
+		response.JSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		response.JSONError(w, http.StatusBadRequest, "Batch contains no items")
+		return
+	}
+
+	tenantID := tenant.FromRequest(r)
+	result := BatchResult{
+		BatchID: uuid.New(),
+		Total:   len(items),
+		Items:   make([]BatchItemResult, len(items)),
+	}
+
+	// Validate every item on its own so one bad item doesn't sink the batch
+	var (
+		contents []models.Content
+		indexes  []int
+	)
+	validate := validator.Validtor()
+	for i, item := range items {
+		result.Items[i].Index = i
+		if err := validate.Struct(item); err != nil {
+			result.Items[i].Error = fmt.Sprintf("Validation error: %v", err)
+			continue
+		}
+		contents = append(contents, models.Content{
+			TenantID: tenantID,
+			BatchID:  &result.BatchID,
+			AuthorID: item.AuthorID,
+			Text:     item.Text,
+			Image:    item.Image,
+			Video:    item.Video,
+		})
+		indexes = append(indexes, i)
+	}
+
+	// The whole batch fits in the quota or none of it is accepted
+	if len(contents) > 0 && !h.consumeQuota(w, r, len(contents)) {
+		return
+	}
+
+	batch := models.Batch{
+		ID:       result.BatchID,
+		TenantID: tenantID,
+		Total:    len(items),
+		Accepted: len(contents),
+		Rejected: len(items) - len(contents),
+	}
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Batches.Create(ctx, &batch); err != nil {
+			return err
+		}
+		if len(contents) == 0 {
+			return nil
+		}
+		if err := tx.Contents.CreateMany(ctx, contents); err != nil {
+			return err
+		}
+
+		events := make([]models.ModerationEvents, len(contents))
+		for i, content := range contents {
+			events[i] = models.ModerationEvents{
+				ContentId: content.ID,
+				EventType: models.Created,
+				Status:    models.Pending,
+			}
+		}
+		return tx.Events.CreateMany(ctx, events)
+	})
+	if err != nil {
+		h.refundQuota(ctx, r, len(contents))
+		response.JSONError(w, http.StatusInternalServerError, "Failed to create batch")
+		return
+	}
+
+	for i, content := range contents {
+		id := content.ID
+		result.Items[indexes[i]].ID = &id
+	}
+
+	// Items whose tasks couldn't all be enqueued are dropped from the batch
+	var failed []uuid.UUID
+	for i, err := range moderation.EnqueueMany(ctx, contents) {
+		content := contents[i]
+		if err != nil {
+			result.Items[indexes[i]].ID = nil
+			result.Items[indexes[i]].Error = err.Error()
+			failed = append(failed, content.ID)
+			continue
+		}
+		stream.Publish(ctx, stream.Event{
+			Type:      stream.ContentCreated,
+			ContentID: content.ID,
+			TenantID:  content.TenantID,
+			Status:    models.Pending,
+		})
+	}
+	if len(failed) > 0 {
+		h.dropUnqueued(ctx, r, &batch, failed)
+	}
+
+	for _, item := range result.Items {
+		if item.Error == "" {
+			result.Accepted++
+		} else {
+			result.Rejected++
+		}
+	}
+
+	status := http.StatusCreated
+	if result.Accepted == 0 {
+		status = http.StatusBadRequest
+	}
+	response.JSON(w, status, result)
+}
+
+// dropUnqueued removes the items whose tasks couldn't be enqueued, so they
+// don't sit PENDING forever, counts them as rejected and gives back their
+// quota. Tasks already enqueued for a removed item find nothing to moderate.
+func (h *Handler) dropUnqueued(ctx context.Context, r *http.Request, batch *models.Batch, ids []uuid.UUID) {
+	logger := logging.FromContext(ctx)
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Contents.DeleteMany(ctx, ids); err != nil {
+			return err
+		}
+		batch.Accepted -= len(ids)
+		batch.Rejected += len(ids)
+		return tx.Batches.Save(ctx, batch)
+	})
+	if err != nil {
+		logger.Error("failed to remove batch items that weren't enqueued", "batch_id", batch.ID, "items", len(ids), "error", err)
+		return
+	}
+	h.refundQuota(ctx, r, len(ids))
+}
+
+func (h *Handler) GetBatchProgress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid batch ID")
+		return
+	}
+
+	batch, err := h.repos.Batches.Get(r.Context(), id, tenant.FromRequest(r))
+	if err != nil {
+		response.JSONError(w, http.StatusNotFound, "Batch not found")
+		return
+	}
+
+	counts, _ := h.repos.Batches.StatusCounts(r.Context(), id)
+
+	progress := BatchProgress{
+		BatchID:   batch.ID,
+		Total:     batch.Total,
+		Accepted:  batch.Accepted,
+		Rejected:  batch.Rejected,
+		Statuses:  make(map[string]int64),
+		CreatedAt: batch.CreatedAt,
+	}
+	for _, c := range counts {
+		// Content without a final status has not been aggregated yet
+		if c.Label == "" || c.Label == string(models.Pending) {
+			progress.Pending += c.Value
+		} else {
+			progress.Completed += c.Value
+		}
+		label := c.Label
+		if label == "" {
+			label = string(models.Pending)
+		}
+		progress.Statuses[label] += c.Value
+	}
+
+	response.JSON(w, http.StatusOK, progress)
+}
+
+// decodeJSONBatch reads {"items": [...]} one item at a time, stopping as
+// soon as the limit is crossed instead of decoding the whole body first
+func decodeJSONBatch(body io.Reader) ([]batchItem, error) {
+	errInvalid := fmt.Errorf("Invalid request body")
+	dec := json.NewDecoder(body)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errInvalid
+	}
+	var items []batchItem
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, errInvalid
+		}
+		if name, _ := key.(string); !strings.EqualFold(name, "items") {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, errInvalid
+			}
+			continue
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errInvalid
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return nil, errInvalid
+		}
+		for dec.More() {
+			if len(items) == maxBatchItems {
+				return nil, errTooManyItems
+			}
+			var item batchItem
+			if err := dec.Decode(&item); err != nil {
+				return nil, errInvalid
+			}
+			items = append(items, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, errInvalid
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, errInvalid
+	}
+	return items, nil
+}
+
+// decodeNDJSON reads one item per line, stopping as soon as the limit is crossed
+// instead of buffering the whole body
+func decodeNDJSON(body io.Reader) ([]batchItem, error) {
+	var items []batchItem
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		if len(items) == maxBatchItems {
+			return nil, errTooManyItems
+		}
+		var item batchItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("Invalid JSON on line %d", line)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read request body: %v", err)
+	}
+	return items, nil
+}
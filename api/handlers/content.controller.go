@@ -2,28 +2,85 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/logging"
 	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/moderation"
 	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
 	"github.com/Sreejit-Sengupto/utils/validator"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
-func GetAllContent(w http.ResponseWriter, r *http.Request) {
-	var contents []models.Content
-	db := database.DB
-	result := db.Find(&contents)
-	if result.Error != nil {
-		response.JSONError(w, http.StatusNotFound, "Failed to fetch all content")
+type ContentPage struct {
+	Data       []models.Content `json:"data"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	HasMore    bool             `json:"hasMore"`
+	Total      *int64           `json:"total,omitempty"`
+}
+
+func (h *Handler) GetAllContent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := listing.ParseContentFilter(query)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeToTenant(w, r, &filter) {
+		return
+	}
+
+	page, err := listing.ParsePage(query)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	response.JSON(w, http.StatusOK, contents)
+
+	contents, err := h.repos.Contents.List(r.Context(), filter, page)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to fetch all content")
+		return
+	}
+
+	contents, next := page.Next(contents)
+	res := ContentPage{
+		Data:       contents,
+		NextCursor: next,
+		HasMore:    next != "",
+	}
+
+	// Counting is opt-in since it scans every matching row
+	if page.Count {
+		total, err := h.repos.Contents.Count(r.Context(), filter)
+		if err != nil {
+			response.JSONError(w, http.StatusInternalServerError, "Failed to count content")
+			return
+		}
+		res.Total = &total
+	}
+
+	response.JSON(w, http.StatusOK, res)
 }
 
-func GetContentByID(w http.ResponseWriter, r *http.Request) {
+// scopeToTenant restricts a content filter to the request's tenant, rejecting
+// a tenantId naming any other
+func scopeToTenant(w http.ResponseWriter, r *http.Request, filter *listing.ContentFilter) bool {
+	tenantID := tenant.FromRequest(r)
+	if filter.TenantID != "" && filter.TenantID != tenantID {
+		response.JSONError(w, http.StatusForbidden, "tenantId must be the tenant the request is made for")
+		return false
+	}
+	filter.TenantID = tenantID
+	return true
+}
+
+func (h *Handler) GetContentByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -33,16 +90,15 @@ func GetContentByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.DB
-	var content models.Content
-	result := db.Find(&content, models.Content{ID: id})
-	if result.Error != nil {
+	content, err := h.repos.Contents.Get(r.Context(), id)
+	if err != nil {
 		response.JSONError(w, http.StatusNotFound, "Failed to fetch content details for the id "+idStr)
+		return
 	}
 	response.JSON(w, http.StatusOK, content)
 }
 
-func GetModerationResults(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetModerationResults(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
@@ -51,13 +107,12 @@ func GetModerationResults(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.DB
-
-	var content models.Content
-
-	result := db.Preload("ModerationResult").Find(&content, models.Content{ID: id})
-	if result.Error != nil {
-		fmt.Println(result.Error)
+	content, err := h.repos.Contents.Get(r.Context(), id)
+	if err == nil {
+		content.ModerationResult, err = h.repos.Results.ListByContent(r.Context(), id)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to fetch content", "content_id", id, "error", err)
 		response.JSONError(w, http.StatusNotFound, "Failed to fetch results")
 		return
 	}
@@ -65,7 +120,7 @@ func GetModerationResults(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, content)
 }
 
-func GetModerationEvents(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetModerationEvents(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
@@ -74,18 +129,18 @@ func GetModerationEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.DB
-
-	var content models.Content
-	result := db.Preload("ModerationEvents").Find(&content, models.Content{ID: id})
-	if result.Error != nil {
+	content, err := h.repos.Contents.Get(r.Context(), id)
+	if err == nil {
+		content.ModerationEvents, err = h.repos.Events.ListByContent(r.Context(), id)
+	}
+	if err != nil {
 		response.JSONError(w, http.StatusNotFound, "Failed to fetch events")
 		return
 	}
 	response.JSON(w, http.StatusOK, content)
 }
 
-func GetModerationAudits(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetModerationAudits(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := uuid.Parse(idStr)
@@ -94,18 +149,18 @@ func GetModerationAudits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.DB
-
-	var content models.Content
-	result := db.Preload("Audit").Find(&content, models.Content{ID: id})
-	if result.Error != nil {
+	content, err := h.repos.Contents.Get(r.Context(), id)
+	if err == nil {
+		content.Audit, err = h.repos.Audits.ListByContent(r.Context(), id)
+	}
+	if err != nil {
 		response.JSONError(w, http.StatusNotFound, "Failed to fetch events")
 		return
 	}
 	response.JSON(w, http.StatusOK, content)
 }
 
-func UpdateContent(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateContent(w http.ResponseWriter, r *http.Request) {
 	var reqBody struct {
 		ContentID   uuid.UUID `json:"contentId" validate:"required"`
 		Reason      string    `json:"reason" validate:"required"`
@@ -125,30 +180,21 @@ func UpdateContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.DB
-
-	var content models.Content
-
-	result := db.Find(&content, models.Content{ID: reqBody.ContentID})
-	if result.Error != nil {
-		response.JSONError(w, http.StatusNotFound, "Failed to fetch all content")
-	}
-	content.TextStatus = models.ContentStatus(reqBody.TextStatus)
-	content.ImageStatus = models.ContentStatus(reqBody.ImageStatus)
-	content.VideoStatus = models.ContentStatus(reqBody.VideoStatus)
-	content.FinalStatus = models.ContentStatus(reqBody.FinalStatus)
-
-	db.Save(&content)
-
-	auditLogs := models.Audit{
-		ContentId: reqBody.ContentID,
-		Action:    "OVERIDDEN",
-		Reason:    reqBody.Reason,
+	_, err := moderation.ApplyOverride(r.Context(), h.repos, moderation.Override{
+		ContentID:   reqBody.ContentID,
+		Reason:      reqBody.Reason,
+		TextStatus:  models.ContentStatus(reqBody.TextStatus),
+		ImageStatus: models.ContentStatus(reqBody.ImageStatus),
+		VideoStatus: models.ContentStatus(reqBody.VideoStatus),
+		FinalStatus: models.ContentStatus(reqBody.FinalStatus),
+	})
+	if errors.Is(err, moderation.ErrContentNotFound) {
+		response.JSONError(w, http.StatusNotFound, "Content not found")
+		return
 	}
-	result = db.Create(&auditLogs)
-
-	if result.Error != nil {
-		response.JSONError(w, http.StatusBadRequest, "Failed to insert audit logs")
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to override content", "content_id", reqBody.ContentID, "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to update content")
 		return
 	}
 
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/Sreejit-Sengupto/internal/export"
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hibiken/asynq"
+)
+
+// maxInlineExportRows is the largest export streamed straight from the request;
+// anything bigger has to go through a background export
+const maxInlineExportRows = 10000
+
+func (h *Handler) StreamExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := listing.ParseContentFilter(query)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeToTenant(w, r, &filter) {
+		return
+	}
+
+	total, err := h.repos.Contents.Count(r.Context(), filter)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to count content")
+		return
+	}
+	if total > maxInlineExportRows {
+		response.JSONError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Export matches %d items, more than %d; use POST /exports instead", total, maxInlineExportRows))
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"content-export.%s\"", export.Extension(format)))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure midway can only be logged
+	if _, err := export.Write(r.Context(), h.repos.Contents, w, format, filter); err != nil {
+		logging.FromContext(r.Context()).Error("streamed export failed", "error", err)
+	}
+}
+
+func (h *Handler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Format  string            `json:"format"`
+		Filters map[string]string `json:"filters"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	format, err := export.ParseFormat(reqBody.Format)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate the filters up front rather than failing in the worker
+	values := url.Values{}
+	for k, v := range reqBody.Filters {
+		values.Set(k, v)
+	}
+	filter, err := listing.ParseContentFilter(values)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeToTenant(w, r, &filter) {
+		return
+	}
+
+	if reqBody.Filters == nil {
+		reqBody.Filters = map[string]string{}
+	}
+	reqBody.Filters["tenantId"] = filter.TenantID
+	filters, err := json.Marshal(reqBody.Filters)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid filters")
+		return
+	}
+
+	job := models.Export{
+		TenantID: tenant.FromRequest(r),
+		Format:   format,
+		Filters:  filters,
+		Status:   models.ExportPending,
+	}
+	if err := h.repos.Exports.Create(r.Context(), &job); err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to create export")
+		return
+	}
+
+	task, err := tasks.NewExportDeliveryTask(job.ID)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to create export task")
+		return
+	}
+	info, err := workerClient.Client.Enqueue(task, asynq.Queue(tasks.QueueExport))
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to enqueue export task")
+		return
+	}
+	logging.FromContext(r.Context()).Info("enqueued task", "task_id", info.ID, "queue", info.Queue)
+
+	response.JSON(w, http.StatusAccepted, job)
+}
+
+func (h *Handler) GetExports(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.repos.Exports.List(r.Context(), tenant.FromRequest(r), 100)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to fetch exports")
+		return
+	}
+	response.JSON(w, http.StatusOK, exports)
+}
+
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+	response.JSON(w, http.StatusOK, job)
+}
+
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.ExportCompleted {
+		response.JSONError(w, http.StatusConflict, fmt.Sprintf("Export is %s", job.Status))
+		return
+	}
+
+	file, err := export.Store.Open(job.Location)
+	if err != nil {
+		response.JSONError(w, http.StatusNotFound, "Export file not found")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", export.ContentType(job.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"export-%s.%s\"", job.ID, export.Extension(job.Format)))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure midway can only be logged
+	if _, err := io.Copy(w, file); err != nil {
+		logging.FromContext(r.Context()).Error("export download failed", "export_id", job.ID, "error", err)
+	}
+}
+
+func (h *Handler) findExport(w http.ResponseWriter, r *http.Request) (models.Export, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid export ID")
+		return models.Export{}, false
+	}
+
+	job, err := h.repos.Exports.Get(r.Context(), id)
+	if err != nil || job.TenantID != tenant.FromRequest(r) {
+		response.JSONError(w, http.StatusNotFound, "Export not found")
+		return job, false
+	}
+	return job, true
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/ratelimit"
+	"github.com/Sreejit-Sengupto/internal/repository"
+)
+
+// Handler serves the endpoints that read or write moderation data, through
+// the repositories it is given
+type Handler struct {
+	repos *repository.Repositories
+	opts  Options
+
+	// lastIdempotencyPurge is when expired idempotency keys were last deleted, in Unix nanoseconds
+	lastIdempotencyPurge atomic.Int64
+}
+
+type Options struct {
+	// IdempotencyRetention is how long responses to requests with an
+	// Idempotency-Key are replayed
+	IdempotencyRetention time.Duration
+	// MaxIdempotentBody caps the body of a request with an Idempotency-Key
+	MaxIdempotentBody int
+	// APIKeys maps registered API keys to their tenants; empty lets requests
+	// name their tenant
+	APIKeys map[string]string
+	// Limiter enforces rate limits and quotas; nil disables them
+	Limiter *ratelimit.Limiter
+}
+
+func New(repos *repository.Repositories, opts Options) *Handler {
+	return &Handler{repos: repos, opts: opts}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/health"
+	"github.com/Sreejit-Sengupto/utils/response"
+)
+
+var startedAt = time.Now()
+
+type Liveness struct {
+	Status        health.Status `json:"status"`
+	UptimeSeconds int64         `json:"uptimeSeconds"`
+}
+
+// GetLiveness reports that the process is up without touching any dependency
+func GetLiveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, Liveness{
+		Status:        health.OK,
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// GetReadiness checks every dependency this process uses. It answers 503 only
+// when a critical dependency is down; a DEGRADED service still takes traffic.
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	report := health.Ready(r.Context())
+
+	status := http.StatusOK
+	if report.Status == health.Down {
+		status = http.StatusServiceUnavailable
+	}
+	response.JSON(w, status, report)
+}
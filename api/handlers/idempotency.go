@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+)
+
+const (
+	// IdempotencyHeader names the key a client sends to make retries safe
+	IdempotencyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response replayed from an earlier request
+	ReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKey caps the length of a key
+	maxIdempotencyKey = 255
+	// idempotencyInFlight is how long a key stays reserved for a request that
+	// hasn't finished, so one that died with its process stops blocking retries
+	idempotencyInFlight = 5 * time.Minute
+	// idempotencyPurgeInterval is how often expired keys are deleted
+	idempotencyPurgeInterval = time.Hour
+)
+
+// Idempotent makes next safe to retry with an Idempotency-Key header. The
+// first request with a key runs and its response is stored; repeats of it
+// within the retention get that response back, a different request under the
+// same key is rejected with 422, and one arriving while the first is still
+// running gets 409. Requests without the header run as usual.
+func (h *Handler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			response.JSONError(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyHeader, maxIdempotencyKey))
+			return
+		}
+
+		// The body is read up front to fingerprint the request, then handed on
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(h.opts.MaxIdempotentBody)))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.JSONError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body with an %s must be at most %d bytes", IdempotencyHeader, tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			response.JSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		h.purgeIdempotencyKeys(r.Context())
+
+		reservation := models.IdempotencyKey{
+			TenantID:    tenant.FromRequest(r),
+			Key:         key,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   time.Now().Add(idempotencyInFlight),
+		}
+		held, reserved, err := h.repos.Idempotency.Reserve(r.Context(), &reservation)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("failed to reserve idempotency key", "error", err)
+			response.JSONError(w, http.StatusInternalServerError, "Failed to check "+IdempotencyHeader)
+			return
+		}
+		if !reserved {
+			switch {
+			case held.RequestHash != reservation.RequestHash:
+				response.JSONError(w, http.StatusUnprocessableEntity, IdempotencyHeader+" was already used for a different request")
+			case held.StatusCode == 0:
+				response.JSONError(w, http.StatusConflict, "A request with this "+IdempotencyHeader+" is still being processed")
+			default:
+				if held.ContentType != "" {
+					w.Header().Set("Content-Type", held.ContentType)
+				}
+				w.Header().Set(ReplayedHeader, "true")
+				w.WriteHeader(held.StatusCode)
+				io.WriteString(w, held.ResponseBody)
+			}
+			return
+		}
+
+		// Storing the response must not depend on the client still waiting for it
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			// A panicking handler leaves nothing to replay, and the responses
+			// that aren't kept free the key for a retry
+			if !completed {
+				if err := h.repos.Idempotency.Release(ctx, reservation.TenantID, reservation.Key); err != nil {
+					logging.FromContext(ctx).Error("failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		// Client errors are kept, since the same request would fail the same
+		// way. A 429 is not: the request was refused before taking effect and
+		// would be replayed without its Retry-After. Neither are server
+		// errors, so the same key can retry them.
+		if rec.status == http.StatusTooManyRequests || rec.status >= http.StatusInternalServerError {
+			return
+		}
+		reservation.StatusCode = rec.status
+		if reservation.StatusCode == 0 {
+			reservation.StatusCode = http.StatusOK
+		}
+		reservation.ContentType = w.Header().Get("Content-Type")
+		reservation.ResponseBody = rec.body.String()
+		reservation.ExpiresAt = time.Now().Add(h.opts.IdempotencyRetention)
+		if err := h.repos.Idempotency.Complete(ctx, &reservation); err != nil {
+			logging.FromContext(ctx).Error("failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// requestHash fingerprints what the request asks for: its method, path,
+// content type and body
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// purgeIdempotencyKeys deletes expired keys in the background, at most once
+// per interval in each process
+func (h *Handler) purgeIdempotencyKeys(ctx context.Context) {
+	now := time.Now()
+	last := h.lastIdempotencyPurge.Load()
+	if now.UnixNano()-last < int64(idempotencyPurgeInterval) || !h.lastIdempotencyPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		deleted, err := h.repos.Idempotency.DeleteExpired(ctx, now)
+		if err != nil {
+			logger.Error("failed to delete expired idempotency keys", "error", err)
+			return
+		}
+		if deleted > 0 {
+			logger.Info("deleted expired idempotency keys", "count", deleted)
+		}
+	}()
+}
+
+// idempotencyRecorder keeps a copy of the response while writing it through
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
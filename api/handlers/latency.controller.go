@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/google/uuid"
+)
+
+// LatencyStats summarises durations in seconds
+type LatencyStats struct {
+	Count     int      `json:"count"`
+	Mean      float64  `json:"mean"`
+	P50       float64  `json:"p50"`
+	P90       float64  `json:"p90"`
+	P99       float64  `json:"p99"`
+	Max       float64  `json:"max"`
+	WithinSLA *float64 `json:"withinSla,omitempty"`
+}
+
+type ModalityLatency struct {
+	MediaType string `json:"mediaType"`
+	Queue     string `json:"queue"`
+	LatencyStats
+}
+
+type LatencyReport struct {
+	// TimeToDecision runs from upload to the aggregation that settled the final status
+	TimeToDecision LatencyStats `json:"timeToDecision"`
+	// ByModality runs from upload to each modality's model verdict
+	ByModality []ModalityLatency `json:"byModality"`
+	// TimeInReview runs from the automated decision to the reviewer's, for reviewed items only
+	TimeInReview LatencyStats    `json:"timeInReview"`
+	SLASeconds   *float64        `json:"slaSeconds,omitempty"`
+	Range        timerange.Range `json:"range"`
+}
+
+// GetModerationLatency derives stage durations from the moderation events of
+// content created in the window. Content without a CREATED event falls back
+// to the content's own creation time.
+func (h *Handler) GetModerationLatency(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseAnalyticsRange(w, r, summarySpan)
+	if !ok {
+		return
+	}
+
+	var sla time.Duration
+	if raw := r.URL.Query().Get("sla"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			response.JSONError(w, http.StatusBadRequest, "sla must be a positive duration such as 90s or 5m")
+			return
+		}
+		sla = d
+	}
+
+	type modalityKey struct {
+		mediaType models.MediaType
+		queue     string
+	}
+
+	var (
+		decision []float64
+		review   []float64
+		modality = make(map[modalityKey][]float64)
+	)
+
+	// The events of one content item arrive together, in order
+	var (
+		current         uuid.UUID
+		created         time.Time
+		lastAggregated  time.Time
+		reviewed        time.Time
+		decidedAtReview time.Time
+		seenModality    map[modalityKey]bool
+	)
+	flush := func() {
+		if current == uuid.Nil {
+			return
+		}
+		if !reviewed.IsZero() && !decidedAtReview.IsZero() {
+			decision = append(decision, decidedAtReview.Sub(created).Seconds())
+			review = append(review, reviewed.Sub(decidedAtReview).Seconds())
+		} else if !lastAggregated.IsZero() {
+			decision = append(decision, lastAggregated.Sub(created).Seconds())
+		}
+	}
+
+	err := h.repos.Analytics.StageEvents(r.Context(), rng, func(row repository.StageEvent) error {
+		if row.ContentID != current {
+			flush()
+			current = row.ContentID
+			created = row.ContentCreatedAt
+			lastAggregated, reviewed, decidedAtReview = time.Time{}, time.Time{}, time.Time{}
+			seenModality = make(map[modalityKey]bool)
+		}
+
+		switch row.EventType {
+		case models.Created:
+			created = row.CreatedAt
+		case models.Moderated:
+			// Only the first verdict per modality counts; later ones are re-moderations
+			key := modalityKey{row.MediaType, row.Queue}
+			if !seenModality[key] {
+				seenModality[key] = true
+				modality[key] = append(modality[key], row.CreatedAt.Sub(created).Seconds())
+			}
+		case models.Aggregated:
+			if reviewed.IsZero() {
+				lastAggregated = row.CreatedAt
+			}
+		case models.HumanReviewed:
+			if reviewed.IsZero() {
+				reviewed = row.CreatedAt
+				decidedAtReview = lastAggregated
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read moderation events")
+		return
+	}
+	flush()
+
+	report := LatencyReport{
+		TimeToDecision: latencyStats(decision, sla),
+		TimeInReview:   latencyStats(review, 0),
+		ByModality:     []ModalityLatency{},
+		Range:          rng,
+	}
+	if sla > 0 {
+		s := sla.Seconds()
+		report.SLASeconds = &s
+	}
+	for key, durations := range modality {
+		report.ByModality = append(report.ByModality, ModalityLatency{
+			MediaType:    string(key.mediaType),
+			Queue:        key.queue,
+			LatencyStats: latencyStats(durations, sla),
+		})
+	}
+	sort.Slice(report.ByModality, func(i, j int) bool {
+		if report.ByModality[i].MediaType != report.ByModality[j].MediaType {
+			return report.ByModality[i].MediaType < report.ByModality[j].MediaType
+		}
+		return report.ByModality[i].Queue < report.ByModality[j].Queue
+	})
+
+	response.JSON(w, http.StatusOK, report)
+}
+
+func latencyStats(durations []float64, sla time.Duration) LatencyStats {
+	stats := LatencyStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sort.Float64s(durations)
+
+	var sum float64
+	within := 0
+	for _, d := range durations {
+		sum += d
+		if sla > 0 && d <= sla.Seconds() {
+			within++
+		}
+	}
+
+	stats.Mean = sum / float64(len(durations))
+	stats.P50 = percentile(durations, 0.50)
+	stats.P90 = percentile(durations, 0.90)
+	stats.P99 = percentile(durations, 0.99)
+	stats.Max = durations[len(durations)-1]
+	if sla > 0 {
+		stats.WithinSLA = ratio(int64(within), int64(len(durations)))
+	}
+	return stats
+}
+
+// percentile uses the nearest-rank method on sorted durations
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
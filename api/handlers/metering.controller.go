@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metering"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxUsageDays bounds the span of a usage report
+const maxUsageDays = 366
+
+const dayLayout = "2006-01-02"
+
+// UsageTotals adds up model calls; Model is only set in per-model totals
+type UsageTotals struct {
+	Model        string  `json:"model,omitempty"`
+	Calls        int64   `json:"calls"`
+	InputTokens  int64   `json:"inputTokens"`
+	OutputTokens int64   `json:"outputTokens"`
+	Cost         float64 `json:"cost"`
+}
+
+type UsageDay struct {
+	Day string `json:"day"`
+	UsageTotals
+}
+
+type UsageReport struct {
+	TenantID string `json:"tenantId"`
+	// From and To are UTC days; To is exclusive
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Days   []UsageDay    `json:"days"`
+	Models []UsageTotals `json:"models"`
+	Total  UsageTotals   `json:"total"`
+}
+
+type ContentUsage struct {
+	ContentID uuid.UUID          `json:"contentId"`
+	Calls     []models.ModelCall `json:"calls"`
+	Total     UsageTotals        `json:"total"`
+}
+
+type BudgetStatus struct {
+	TenantID string `json:"tenantId"`
+	Month    string `json:"month"`
+	// Budget is 0 when the tenant has none, and Remaining is then omitted
+	Budget    float64              `json:"budget"`
+	Cost      float64              `json:"cost"`
+	Remaining *float64             `json:"remaining,omitempty"`
+	Exceeded  bool                 `json:"exceeded"`
+	Alerts    []models.BudgetAlert `json:"alerts"`
+}
+
+// GetUsageReport reports the tenant's model usage and cost per UTC day and
+// model between from and to (YYYY-MM-DD, to exclusive), by default the
+// current month so far
+func (h *Handler) GetUsageReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+	from, _, _ := metering.Month(now)
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if raw := query.Get("from"); raw != "" {
+		if from, err = time.Parse(dayLayout, raw); err != nil {
+			response.JSONError(w, http.StatusBadRequest, "from must be a date as YYYY-MM-DD")
+			return
+		}
+	}
+	if raw := query.Get("to"); raw != "" {
+		if to, err = time.Parse(dayLayout, raw); err != nil {
+			response.JSONError(w, http.StatusBadRequest, "to must be a date as YYYY-MM-DD")
+			return
+		}
+	}
+	if !from.Before(to) {
+		response.JSONError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if to.Sub(from) > maxUsageDays*24*time.Hour {
+		response.JSONError(w, http.StatusBadRequest, fmt.Sprintf("A usage report covers at most %d days", maxUsageDays))
+		return
+	}
+
+	tenantID := tenant.FromRequest(r)
+	days, err := h.repos.Usage.Days(r.Context(), tenantID, from, to)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read model usage", "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read usage")
+		return
+	}
+
+	report := UsageReport{
+		TenantID: tenantID,
+		From:     from.Format(dayLayout),
+		To:       to.Format(dayLayout),
+		Days:     make([]UsageDay, len(days)),
+		Models:   []UsageTotals{},
+	}
+	byModel := map[string]int{}
+	for i, d := range days {
+		totals := UsageTotals{
+			Model:        d.Model,
+			Calls:        d.Calls,
+			InputTokens:  d.InputTokens,
+			OutputTokens: d.OutputTokens,
+			Cost:         d.Cost,
+		}
+		report.Days[i] = UsageDay{Day: d.Day.UTC().Format(dayLayout), UsageTotals: totals}
+
+		j, ok := byModel[d.Model]
+		if !ok {
+			j = len(report.Models)
+			byModel[d.Model] = j
+			report.Models = append(report.Models, UsageTotals{Model: d.Model})
+		}
+		report.Models[j].add(totals)
+		report.Total.add(totals)
+	}
+
+	response.JSON(w, http.StatusOK, report)
+}
+
+// GetContentUsage lists the model calls made to moderate one content item
+func (h *Handler) GetContentUsage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	calls, err := h.repos.Usage.ListByContent(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read model usage", "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read usage")
+		return
+	}
+
+	// Calls are kept after their content is deleted, so the tenant is checked on them
+	usage := ContentUsage{ContentID: id, Calls: []models.ModelCall{}}
+	tenantID := tenant.FromRequest(r)
+	for _, call := range calls {
+		if call.TenantID != tenantID {
+			continue
+		}
+		usage.Calls = append(usage.Calls, call)
+		usage.Total.add(UsageTotals{Calls: 1, InputTokens: call.InputTokens, OutputTokens: call.OutputTokens, Cost: call.Cost})
+	}
+
+	response.JSON(w, http.StatusOK, usage)
+}
+
+// GetBudget reports the tenant's spend this UTC month against its budget,
+// with the alerts raised so far
+func (h *Handler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	tenantID := tenant.FromRequest(r)
+	from, to, month := metering.Month(time.Now())
+
+	cost, err := h.repos.Usage.Cost(r.Context(), tenantID, from, to)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read model cost", "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read budget")
+		return
+	}
+	alerts, err := h.repos.Usage.ListAlerts(r.Context(), tenantID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read budget alerts", "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read budget")
+		return
+	}
+
+	status := BudgetStatus{
+		TenantID: tenantID,
+		Month:    month,
+		Budget:   metering.Budget(tenantID),
+		Cost:     cost,
+		Alerts:   alerts,
+	}
+	if status.Alerts == nil {
+		status.Alerts = []models.BudgetAlert{}
+	}
+	if status.Budget > 0 {
+		remaining := max(status.Budget-cost, 0)
+		status.Remaining = &remaining
+		status.Exceeded = cost >= status.Budget
+	}
+
+	response.JSON(w, http.StatusOK, status)
+}
+
+func (t *UsageTotals) add(o UsageTotals) {
+	t.Calls += o.Calls
+	t.InputTokens += o.InputTokens
+	t.OutputTokens += o.OutputTokens
+	t.Cost += o.Cost
+}
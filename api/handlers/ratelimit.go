@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
+	"github.com/Sreejit-Sengupto/internal/ratelimit"
+	"github.com/Sreejit-Sengupto/utils/response"
+)
+
+// RateLimit takes a token from the caller's bucket for every request and
+// answers 429 once it is empty. The RateLimit-* headers report the bucket on
+// every response. A limiter that can't be reached lets requests through, so
+// an outage of its Redis doesn't take the API down with it.
+func (h *Handler) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.opts.Limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		decision, err := h.opts.Limiter.Allow(r.Context(), h.opts.Limiter.Client(r))
+		if err != nil {
+			logging.FromContext(r.Context()).Error("failed to check rate limit", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+		if !decision.Allowed {
+			metrics.RateLimited.WithLabelValues("rate").Inc()
+			w.Header().Set("Retry-After", ceilSeconds(decision.RetryAfter))
+			response.JSONError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// consumeQuota counts n submissions against the caller's quotas. When that
+// would exceed one, it answers 429 and returns false.
+func (h *Handler) consumeQuota(w http.ResponseWriter, r *http.Request, n int) bool {
+	if h.opts.Limiter == nil {
+		return true
+	}
+
+	decision, err := h.opts.Limiter.Consume(r.Context(), h.quotaSubject(r), n)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to check quota", "error", err)
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	quota := decision.Exceeded
+	metrics.RateLimited.WithLabelValues(string(quota.Period)).Inc()
+	w.Header().Set("Retry-After", ceilSeconds(time.Until(quota.ResetsAt)))
+	period := "this month"
+	if quota.Period == ratelimit.Day {
+		period = "today"
+	}
+	response.JSONError(w, http.StatusTooManyRequests, fmt.Sprintf(
+		"Quota exceeded: %d of %d submissions used %s, %d more requested", quota.Used, quota.Limit, period, n))
+	return false
+}
+
+// refundQuota gives back submissions counted by consumeQuota that weren't
+// accepted after all
+func (h *Handler) refundQuota(ctx context.Context, r *http.Request, n int) {
+	if h.opts.Limiter == nil || n == 0 {
+		return
+	}
+	if err := h.opts.Limiter.Refund(ctx, h.quotaSubject(r), n); err != nil {
+		logging.FromContext(ctx).Error("failed to refund quota", "error", err)
+	}
+}
+
+func (h *Handler) quotaSubject(r *http.Request) string {
+	return h.opts.Limiter.QuotaSubject(r)
+}
+
+// ceilSeconds formats d as whole seconds, rounded up as the headers expect
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(max(d, 0).Seconds())))
+}
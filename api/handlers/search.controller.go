@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type ExplanationHit struct {
+	ResultID  uuid.UUID            `json:"resultId"`
+	MediaType models.MediaType     `json:"mediaType"`
+	Status    models.ContentStatus `json:"status"`
+	Highlight string               `json:"highlight"`
+}
+
+type SearchHit struct {
+	Content       models.Content   `json:"content"`
+	Rank          float64          `json:"rank"`
+	TextHighlight string           `json:"textHighlight,omitempty"`
+	Explanations  []ExplanationHit `json:"explanations,omitempty"`
+}
+
+type SearchResults struct {
+	Query   string      `json:"query"`
+	In      string      `json:"in"`
+	Data    []SearchHit `json:"data"`
+	Offset  int         `json:"offset"`
+	HasMore bool        `json:"hasMore"`
+}
+
+func (h *Handler) SearchContent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := query.Get("q")
+	if q == "" {
+		response.JSONError(w, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+
+	in := query.Get("in")
+	switch in {
+	case "":
+		in = "all"
+	case "all", "text", "explanation":
+	default:
+		response.JSONError(w, http.StatusBadRequest, "in must be one of all, text, explanation")
+		return
+	}
+
+	limit, offset, err := parseOffsetPage(query.Get("limit"), query.Get("offset"))
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := listing.ParseContentFilter(query)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeToTenant(w, r, &filter) {
+		return
+	}
+
+	rows, err := h.repos.Contents.Search(r.Context(), repository.SearchQuery{
+		Q:      q,
+		In:     repository.SearchScope(in),
+		Filter: filter,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to search content")
+		return
+	}
+
+	res := SearchResults{Query: q, In: in, Data: []SearchHit{}, Offset: offset}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		res.HasMore = true
+	}
+	if len(rows) == 0 {
+		response.JSON(w, http.StatusOK, res)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ContentID
+	}
+
+	contents, err := h.repos.Contents.GetMany(r.Context(), ids)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to fetch content")
+		return
+	}
+	contentByID := make(map[uuid.UUID]models.Content, len(contents))
+	for _, c := range contents {
+		contentByID[c.ID] = c
+	}
+
+	explanationsByID := make(map[uuid.UUID][]ExplanationHit)
+	if in != "text" {
+		explanations, err := h.repos.Results.SearchExplanations(r.Context(), ids, q)
+		if err != nil {
+			response.JSONError(w, http.StatusInternalServerError, "Failed to search explanations")
+			return
+		}
+		for _, e := range explanations {
+			explanationsByID[e.ContentID] = append(explanationsByID[e.ContentID], ExplanationHit{
+				ResultID:  e.ResultID,
+				MediaType: e.MediaType,
+				Status:    e.Status,
+				Highlight: e.Highlight,
+			})
+		}
+	}
+
+	for _, row := range rows {
+		res.Data = append(res.Data, SearchHit{
+			Content:       contentByID[row.ContentID],
+			Rank:          row.Rank,
+			TextHighlight: row.TextHighlight,
+			Explanations:  explanationsByID[row.ContentID],
+		})
+	}
+
+	response.JSON(w, http.StatusOK, res)
+}
+
+func parseOffsetPage(limitStr, offsetStr string) (int, int, error) {
+	limit, offset := defaultSearchLimit, 0
+	if limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxSearchLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+		}
+		limit = l
+	}
+	if offsetStr != "" {
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+		offset = o
+	}
+	return limit, offset, nil
+}
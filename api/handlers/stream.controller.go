@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/utils/cors"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// heartbeatInterval keeps idle connections from being dropped by proxies
+const heartbeatInterval = 15 * time.Second
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || cors.IsAllowedOrigin(origin)
+	},
+}
+
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.JSONError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events, err := stream.Subscribe(r.Context(), filter)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to subscribe to event stream")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	ctx := r.Context()
+	events, err := stream.Subscribe(ctx, filter)
+	if err != nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "failed to subscribe"))
+		return
+	}
+
+	// The client never sends anything meaningful; reading only detects the close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func parseStreamFilter(r *http.Request) (stream.Filter, error) {
+	query := r.URL.Query()
+	filter := stream.Filter{TenantID: tenant.FromRequest(r)}
+
+	if idStr := query.Get("contentId"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid content ID")
+		}
+		filter.ContentID = &id
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		filter.Statuses = make(map[models.ContentStatus]bool)
+		for _, s := range strings.Split(statuses, ",") {
+			status := models.ContentStatus(strings.ToUpper(strings.TrimSpace(s)))
+			switch status {
+			case models.Pending, models.Approved, models.Rejected, models.Flagged:
+				filter.Statuses[status] = true
+			default:
+				return filter, fmt.Errorf("Invalid status %q", s)
+			}
+		}
+	}
+
+	if types := query.Get("type"); types != "" {
+		filter.Types = make(map[stream.EventType]bool)
+		for _, t := range strings.Split(types, ",") {
+			filter.Types[stream.EventType(strings.TrimSpace(t))] = true
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/ratelimit"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+)
+
+type Usage struct {
+	Client   string `json:"client"`
+	TenantID string `json:"tenantId"`
+	// Subject is the tenant or client the quotas are counted against
+	Subject   string            `json:"subject"`
+	RateLimit RateLimitPolicy   `json:"rateLimit"`
+	Quotas    []ratelimit.Quota `json:"quotas"`
+}
+
+type RateLimitPolicy struct {
+	RequestsPerSecond int `json:"requestsPerSecond"`
+	Burst             int `json:"burst"`
+}
+
+// GetUsage reports the caller's consumption against its quotas for the
+// current UTC day and month
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Limiter == nil {
+		response.JSONError(w, http.StatusNotFound, "Rate limiting and quotas are disabled")
+		return
+	}
+
+	limiter := h.opts.Limiter
+	usage := Usage{
+		Client:   limiter.Client(r),
+		TenantID: tenant.FromRequest(r),
+	}
+	usage.Subject = limiter.QuotaSubject(r)
+	usage.RateLimit.RequestsPerSecond, usage.RateLimit.Burst = limiter.Bucket()
+
+	quotas, err := limiter.Usage(r.Context(), usage.Subject)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read quota usage", "error", err)
+		response.JSONError(w, http.StatusInternalServerError, "Failed to read usage")
+		return
+	}
+	usage.Quotas = quotas
+
+	response.JSON(w, http.StatusOK, usage)
+}
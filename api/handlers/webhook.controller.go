@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/webhooks"
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/Sreejit-Sengupto/utils/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/datatypes"
+)
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		URL         string   `json:"url" validate:"required,url"`
+		Description string   `json:"description"`
+		Events      []string `json:"events" validate:"required,min=1,dive,oneof=content.final_status content.overridden"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := validator.Validtor().Struct(reqBody); err != nil {
+		response.JSONError(w, http.StatusBadRequest, fmt.Sprintf("Validation error: %v", err))
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to generate webhook secret")
+		return
+	}
+
+	events := make([]models.WebhookEvent, 0, len(reqBody.Events))
+	for _, e := range reqBody.Events {
+		events = append(events, models.WebhookEvent(e))
+	}
+
+	subscription := models.WebhookSubscription{
+		TenantID:    tenant.FromRequest(r),
+		URL:         reqBody.URL,
+		Description: reqBody.Description,
+		Secret:      secret,
+		Events:      datatypes.NewJSONSlice(events),
+		Active:      true,
+	}
+	if err := h.repos.Webhooks.CreateSubscription(r.Context(), &subscription); err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to create webhook subscription")
+		return
+	}
+
+	// The secret is only ever returned on creation
+	response.JSON(w, http.StatusCreated, struct {
+		models.WebhookSubscription
+		Secret string `json:"secret"`
+	}{subscription, secret})
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.repos.Webhooks.ListSubscriptions(r.Context(), tenant.FromRequest(r))
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to fetch webhook subscriptions")
+		return
+	}
+	response.JSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	// Deactivate rather than delete so the delivery logs stay intact
+	if err := h.repos.Webhooks.DeactivateSubscription(r.Context(), subscription.ID); err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to delete webhook subscription")
+		return
+	}
+	response.JSON(w, http.StatusOK, "Webhook subscription deleted")
+}
+
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.repos.Webhooks.ListDeliveries(r.Context(), subscription.ID, 100)
+	if err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to fetch webhook deliveries")
+		return
+	}
+	response.JSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, ok := h.findDelivery(w, r)
+	if !ok {
+		return
+	}
+	response.JSON(w, http.StatusOK, delivery)
+}
+
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, ok := h.findDelivery(w, r)
+	if !ok {
+		return
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.LastError = ""
+	if err := h.repos.Webhooks.SaveDelivery(r.Context(), &delivery); err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to reset webhook delivery")
+		return
+	}
+
+	if err := webhooks.Enqueue(delivery.ID); err != nil {
+		response.JSONError(w, http.StatusInternalServerError, "Failed to enqueue webhook delivery")
+		return
+	}
+	response.JSON(w, http.StatusAccepted, "Webhook redelivery queued")
+}
+
+func (h *Handler) findWebhook(w http.ResponseWriter, r *http.Request) (models.WebhookSubscription, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return models.WebhookSubscription{}, false
+	}
+
+	subscription, err := h.repos.Webhooks.GetSubscription(r.Context(), id, tenant.FromRequest(r))
+	if err != nil {
+		response.JSONError(w, http.StatusNotFound, "Webhook subscription not found")
+		return subscription, false
+	}
+	return subscription, true
+}
+
+func (h *Handler) findDelivery(w http.ResponseWriter, r *http.Request) (models.WebhookDelivery, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.JSONError(w, http.StatusBadRequest, "Invalid delivery ID")
+		return models.WebhookDelivery{}, false
+	}
+
+	delivery, err := h.repos.Webhooks.GetDelivery(r.Context(), id)
+	if err != nil || delivery.Subscription.TenantID != tenant.FromRequest(r) {
+		response.JSONError(w, http.StatusNotFound, "Webhook delivery not found")
+		return delivery, false
+	}
+	return delivery, true
+}
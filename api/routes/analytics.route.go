@@ -5,12 +5,14 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func registerAnalyticsRoutes(r *mux.Router) {
-	r.HandleFunc("/analytics/status-distribution", handlers.GetStatusDistribution).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/moderation-over-time", handlers.GetModerationOverTime).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/media-type-breakdown", handlers.GetMediaTypeBreakdown).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/risk-score-distribution", handlers.GetRiskScoreDistribution).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/status-by-media-type", handlers.GetStatusByMediaType).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/audit-activity", handlers.GetAuditActivity).Methods("GET", "OPTIONS")
-	r.HandleFunc("/analytics/summary", handlers.GetModerationSummary).Methods("GET", "OPTIONS")
+func registerAnalyticsRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/analytics/status-distribution", h.GetStatusDistribution).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/moderation-over-time", h.GetModerationOverTime).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/media-type-breakdown", h.GetMediaTypeBreakdown).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/risk-score-distribution", h.GetRiskScoreDistribution).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/status-by-media-type", h.GetStatusByMediaType).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/audit-activity", h.GetAuditActivity).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/summary", h.GetModerationSummary).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/model-agreement", h.GetModelAgreement).Methods("GET", "OPTIONS")
+	r.HandleFunc("/analytics/latency", h.GetModerationLatency).Methods("GET", "OPTIONS")
 }
@@ -5,11 +5,12 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func registerContentRoutes(r *mux.Router) {
-	r.HandleFunc("/content", handlers.GetAllContent).Methods("GET", "OPTIONS")
-	r.HandleFunc("/content/update", handlers.UpdateContent).Methods("PATCH", "OPTIONS")
-	r.HandleFunc("/content/{id}", handlers.GetContentByID).Methods("GET", "OPTIONS")
-	r.HandleFunc("/content/{id}/results", handlers.GetModerationResults).Methods("GET", "OPTIONS")
-	r.HandleFunc("/content/{id}/events", handlers.GetModerationEvents).Methods("GET", "OPTIONS")
-	r.HandleFunc("/content/{id}/audits", handlers.GetModerationAudits).Methods("GET", "OPTIONS")
+func registerContentRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/content", h.GetAllContent).Methods("GET", "OPTIONS")
+	r.HandleFunc("/content/update", h.UpdateContent).Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/content/search", h.SearchContent).Methods("GET", "OPTIONS")
+	r.HandleFunc("/content/{id}", h.GetContentByID).Methods("GET", "OPTIONS")
+	r.HandleFunc("/content/{id}/results", h.GetModerationResults).Methods("GET", "OPTIONS")
+	r.HandleFunc("/content/{id}/events", h.GetModerationEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/content/{id}/audits", h.GetModerationAudits).Methods("GET", "OPTIONS")
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerExportRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/export", h.StreamExport).Methods("GET", "OPTIONS")
+	r.HandleFunc("/exports", h.CreateExport).Methods("POST", "OPTIONS")
+	r.HandleFunc("/exports", h.GetExports).Methods("GET", "OPTIONS")
+	r.HandleFunc("/exports/{id}", h.GetExport).Methods("GET", "OPTIONS")
+	r.HandleFunc("/exports/{id}/download", h.DownloadExport).Methods("GET", "OPTIONS")
+}
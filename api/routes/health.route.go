@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerHealthRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", handlers.GetLiveness).Methods("GET")
+	r.HandleFunc("/readyz", handlers.GetReadiness).Methods("GET")
+}
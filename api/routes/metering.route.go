@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerMeteringRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/metering/usage", h.GetUsageReport).Methods("GET", "OPTIONS")
+	r.HandleFunc("/metering/content/{id}", h.GetContentUsage).Methods("GET", "OPTIONS")
+	r.HandleFunc("/metering/budget", h.GetBudget).Methods("GET", "OPTIONS")
+}
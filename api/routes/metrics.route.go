@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func registerMetricsRoutes(r *mux.Router) {
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+}
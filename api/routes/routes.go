@@ -1,10 +1,31 @@
 package routes
 
-import "github.com/gorilla/mux"
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
 
-func RegisterRoutes(r *mux.Router) {
-	registerUploadRoutes(r)
-	registerContentRoutes(r)
-	registerAnalyticsRoutes(r)
+func RegisterRoutes(r *mux.Router, h *handlers.Handler) {
+	// API calls are authenticated and rate limited per client; probes and
+	// scrapes are not
+	api := r.NewRoute().Subrouter()
+	api.Use(h.Authenticate, h.RateLimit)
+
+	registerUploadRoutes(api, h)
+	registerContentRoutes(api, h)
+	registerAnalyticsRoutes(api, h)
+	registerWebhookRoutes(api, h)
+	registerStreamRoutes(api)
+	registerExportRoutes(api, h)
+	registerUsageRoutes(api, h)
+	registerMeteringRoutes(api, h)
 	registerTestRoutes(r)
+	RegisterOpsRoutes(r)
+}
+
+// RegisterOpsRoutes registers only the metrics and health endpoints, for
+// processes that don't serve the API
+func RegisterOpsRoutes(r *mux.Router) {
+	registerMetricsRoutes(r)
+	registerHealthRoutes(r)
 }
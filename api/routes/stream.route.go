@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerStreamRoutes(r *mux.Router) {
+	r.HandleFunc("/stream/events", handlers.StreamEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/stream/ws", handlers.StreamEventsWebSocket).Methods("GET")
+}
@@ -5,6 +5,9 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func registerUploadRoutes(r *mux.Router) {
-	r.HandleFunc("/upload/content", handlers.UploadContent).Methods("POST")
+func registerUploadRoutes(r *mux.Router, h *handlers.Handler) {
+	// Clients retry uploads on timeouts; an Idempotency-Key keeps that from creating duplicates
+	r.HandleFunc("/upload/content", h.Idempotent(h.UploadContent)).Methods("POST")
+	r.HandleFunc("/upload/batch", h.Idempotent(h.UploadBatch)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/upload/batch/{id}", h.GetBatchProgress).Methods("GET", "OPTIONS")
 }
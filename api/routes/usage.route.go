@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerUsageRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/usage", h.GetUsage).Methods("GET", "OPTIONS")
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func registerWebhookRoutes(r *mux.Router, h *handlers.Handler) {
+	r.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST", "OPTIONS")
+	r.HandleFunc("/webhooks", h.GetWebhooks).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks/deliveries/{id}", h.GetWebhookDelivery).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks/deliveries/{id}/redeliver", h.RedeliverWebhook).Methods("POST", "OPTIONS")
+	r.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/webhooks/{id}/deliveries", h.GetWebhookDeliveries).Methods("GET", "OPTIONS")
+}
@@ -1,123 +1,70 @@
 package main
 
 import (
-	"context"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
-	"time"
+	"slices"
 
-	"github.com/Sreejit-Sengupto/api/routes"
-	"github.com/Sreejit-Sengupto/internal/database"
-	"github.com/Sreejit-Sengupto/internal/models"
-	"github.com/Sreejit-Sengupto/internal/queue"
-	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
-	"github.com/Sreejit-Sengupto/utils/cors"
-	"github.com/Sreejit-Sengupto/utils/gemini"
-	"github.com/Sreejit-Sengupto/utils/imagekit"
-	"github.com/gorilla/mux"
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/tracing"
 	"github.com/joho/godotenv"
 )
 
+const usage = `Usage: moderation [-config file] [mode]
+       moderation [-config file] migrate [status | up | down [N] | to VERSION]
+
+Modes:
+  all           serve the API and process queue tasks in one process (default)
+  serve-api     serve the HTTP API only
+  serve-worker  process queue tasks only, with /metrics and health on worker.metricsAddr
+  migrate       apply pending schema migrations (or run a migrate subcommand) and exit
+`
+
 func main() {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
-	// Connect to database
-	database.ConnectDatabase()
-
-	// Enable uuid-ossp extension
-	database.DB.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"")
-
-	if os.Getenv("RUN_MIGRATION") == "TRUE" {
-		database.DB.AutoMigrate(&models.Content{}, &models.Audit{}, &models.ModerationResult{}, &models.ModerationEvents{})
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	// init imagekit
-	imagekit.InitImageKit()
-
-	// Init gemini (uses context.Background() internally for long-lived client)
-	if err := gemini.InitGemini(); err != nil {
-		log.Fatalf("Failed to initialize Gemini: %v", err)
-		return
+	mode := config.ModeAll
+	if flag.NArg() > 0 {
+		mode = config.Mode(flag.Arg(0))
 	}
-
-	workerClient.InitClient()
-	defer workerClient.CloseClient()
-
-	// Channel to signal worker server shutdown
-	workerShutdown := make(chan struct{})
-
-	// WaitGroup to track goroutines
-	var wg sync.WaitGroup
-
-	// Start worker server
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		queue.StartWorkerServer(workerShutdown)
-	}()
-
-	// Init router
-	r := mux.NewRouter()
-
-	// Route Handlers / Endpoints
-	routes.RegisterRoutes(r)
-
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: cors.Middleware(r),
+	if flag.NArg() > 1 && mode != config.ModeMigrate {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !slices.Contains(config.Modes, mode) {
+		log.Fatalf("Unknown mode %q", mode)
 	}
 
-	// Start HTTP server in goroutine
-	go func() {
-		log.Println("Server started on :8080")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server error: %v", err)
-		}
-	}()
-
-	// Setup signal handling for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	// Wait for shutdown signal
-	sig := <-quit
-	log.Printf("Received signal %v, initiating graceful shutdown...", sig)
-
-	// Create a context with timeout for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Shutdown HTTP server
-	log.Println("Shutting down HTTP server...")
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+	cfg, err := config.Load(*configPath, mode)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	// Signal worker server to shutdown
-	log.Println("Shutting down worker server...")
-	close(workerShutdown)
+	// Structured logging
+	logging.InitLogger(cfg.Log)
 
-	// Wait for worker to finish with timeout
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+	// OpenTelemetry tracing, exported over OTLP when an endpoint is configured
+	tracing.InitTracing(cfg.Tracing)
+	defer tracing.ShutdownTracing()
 
-	select {
-	case <-done:
-		log.Println("All workers shut down gracefully")
-	case <-ctx.Done():
-		log.Println("Shutdown timed out")
+	if mode == config.ModeMigrate {
+		connectDatabase(cfg)
+		migrate(flag.Args()[1:])
+		return
 	}
 
-	log.Println("Server exited")
+	serve(mode, cfg)
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/migrations"
+)
+
+// migrate runs a migrate subcommand: status, up, down [N] or to VERSION.
+// Without one it applies every pending migration.
+func migrate(args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		log.Fatalf("Failed to get DB object: %v", err)
+	}
+	dialect := migrations.Dialect(database.DB.Dialector.Name())
+	ctx := context.Background()
+
+	switch {
+	case command == "status" && len(args) == 0:
+		status, err := migrations.Status(ctx, sqlDB, dialect)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, m := range status {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = m.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", m.Version, m.Name, applied)
+		}
+		tw.Flush()
+
+	case command == "up" && len(args) == 0:
+		if err := migrations.Up(ctx, sqlDB, dialect); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+	case command == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			if steps, err = strconv.Atoi(args[0]); err != nil {
+				log.Fatalf("Invalid number of steps %q", args[0])
+			}
+		}
+		if err := migrations.Down(ctx, sqlDB, dialect, steps); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+	case command == "to" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatalf("Invalid version %q", args[0])
+		}
+		if err := migrations.To(ctx, sqlDB, dialect, version); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+	default:
+		log.Fatalf("Usage: moderation migrate [status | up | down [N] | to VERSION]")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+)
+
+func runBlocklist(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected list, add or remove")
+	}
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("blocklist")
+		tenantID := fs.String("tenant", "", "only list this tenant's terms")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		entries, err := repos.Blocklist.List(ctx, *tenantID)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTENANT\tTERM\tCATEGORY\tREASON")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.ID, e.TenantID, e.Term, e.Category, e.Reason)
+		}
+		return tw.Flush()
+
+	case "add":
+		fs := newFlagSet("blocklist")
+		tenantID := fs.String("tenant", tenant.Default, "tenant ID")
+		term := fs.String("term", "", "term to reject, matched case-insensitively (required)")
+		category := fs.String("category", string(models.Hate), "category recorded on rejected content")
+		reason := fs.String("reason", "", "why the term is blocked")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if strings.TrimSpace(*term) == "" {
+			return errors.New("-term is required")
+		}
+		cat := models.Category(strings.ToUpper(*category))
+		if !slices.Contains(models.Categories, cat) || cat == models.NoCategory {
+			return fmt.Errorf("invalid category %q", *category)
+		}
+
+		entry := models.BlocklistEntry{
+			TenantID: *tenantID,
+			Term:     strings.ToLower(strings.TrimSpace(*term)),
+			Category: cat,
+			Reason:   *reason,
+		}
+		if err := repos.Blocklist.Create(ctx, &entry); err != nil {
+			return err
+		}
+		return printJSON(entry)
+
+	case "remove":
+		if len(args) != 2 {
+			return errors.New("expected one blocklist entry ID")
+		}
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid entry ID: %v", err)
+		}
+		if err := repos.Blocklist.Delete(ctx, id); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return errors.New("blocklist entry not found")
+			}
+			return err
+		}
+		fmt.Printf("%s\tremoved\n", id)
+		return nil
+	}
+
+	return fmt.Errorf("unknown blocklist command %q", args[0])
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/moderation"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+)
+
+var statuses = []models.ContentStatus{models.Pending, models.Approved, models.Rejected, models.Flagged}
+
+func runSubmit(ctx context.Context, args []string) error {
+	fs := newFlagSet("submit")
+	text := fs.String("text", "", "text to moderate (required)")
+	image := fs.String("image", "", "image URL")
+	video := fs.String("video", "", "video URL")
+	tenantID := fs.String("tenant", tenant.Default, "tenant ID")
+	authorID := fs.String("author", "", "author ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *text == "" {
+		return errors.New("-text is required")
+	}
+	if err := requireQueue(); err != nil {
+		return err
+	}
+
+	content := models.Content{
+		TenantID: *tenantID,
+		AuthorID: *authorID,
+		Text:     *text,
+		Image:    *image,
+		Video:    *video,
+	}
+	if err := moderation.Submit(ctx, repos, &content); err != nil {
+		return err
+	}
+	return printJSON(content)
+}
+
+func runShow(ctx context.Context, args []string) error {
+	fs := newFlagSet("show")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("expected one content ID")
+	}
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid content ID: %v", err)
+	}
+
+	content, err := repos.Contents.GetDetailed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printJSON(content)
+}
+
+func runOverride(ctx context.Context, args []string) error {
+	fs := newFlagSet("override")
+	final := fs.String("final", "", "final status (required)")
+	text := fs.String("text", "", "text status, defaults to the current one")
+	image := fs.String("image", "", "image status, defaults to the current one")
+	video := fs.String("video", "", "video status, defaults to the current one")
+	reason := fs.String("reason", "", "reason recorded on the audit (required)")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("expected one content ID")
+	}
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid content ID: %v", err)
+	}
+	if *final == "" || *reason == "" {
+		return errors.New("-final and -reason are required")
+	}
+	// Webhook deliveries for the override go through the queue
+	if err := requireQueue(); err != nil {
+		return err
+	}
+
+	current, err := repos.Contents.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	override := moderation.Override{
+		ContentID:   id,
+		Reason:      *reason,
+		TextStatus:  current.TextStatus,
+		ImageStatus: current.ImageStatus,
+		VideoStatus: current.VideoStatus,
+	}
+	for _, s := range []struct {
+		raw    string
+		target *models.ContentStatus
+	}{
+		{*final, &override.FinalStatus},
+		{*text, &override.TextStatus},
+		{*image, &override.ImageStatus},
+		{*video, &override.VideoStatus},
+	} {
+		if s.raw == "" {
+			continue
+		}
+		status := models.ContentStatus(strings.ToUpper(s.raw))
+		if !slices.Contains(statuses, status) {
+			return fmt.Errorf("invalid status %q", s.raw)
+		}
+		*s.target = status
+	}
+
+	content, err := moderation.ApplyOverride(ctx, repos, override)
+	if err != nil {
+		return err
+	}
+	return printJSON(content)
+}
+
+func runRemoderate(ctx context.Context, args []string) error {
+	fs := newFlagSet("remoderate")
+	var ids, filters multiFlag
+	fs.Var(&ids, "id", "content ID, repeatable")
+	fs.Var(&filters, "filter", "content listing filter as KEY=VALUE (e.g. finalStatus=FLAGGED), repeatable")
+	reason := fs.String("reason", "", "reason recorded on the event (required)")
+	limit := fs.Int("limit", 100, "maximum items to re-moderate when using -filter")
+	dryRun := fs.Bool("dry-run", false, "list the matching content without re-moderating it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *reason == "" && !*dryRun {
+		return errors.New("-reason is required")
+	}
+	if len(ids) == 0 && len(filters) == 0 {
+		return errors.New("give -id or -filter")
+	}
+	if !*dryRun {
+		if err := requireQueue(); err != nil {
+			return err
+		}
+	}
+
+	var targets []uuid.UUID
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid content ID %q: %v", raw, err)
+		}
+		targets = append(targets, id)
+	}
+
+	if len(filters) > 0 {
+		filter, err := parseFilters(filters)
+		if err != nil {
+			return err
+		}
+		page := listing.Page{Sort: "createdAt", Limit: *limit}
+		matched, err := repos.Contents.List(ctx, filter, page)
+		if err != nil {
+			return err
+		}
+		matched, _ = page.Next(matched)
+		for _, content := range matched {
+			targets = append(targets, content.ID)
+		}
+	}
+
+	failed := 0
+	for _, id := range targets {
+		if *dryRun {
+			fmt.Println(id)
+			continue
+		}
+		if _, err := moderation.Remoderate(ctx, repos, id, *reason); err != nil {
+			fmt.Printf("%s\tfailed: %v\n", id, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s\tqueued\n", id)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items failed", failed, len(targets))
+	}
+	return nil
+}
+
+// parseFilters turns KEY=VALUE pairs into the content listing filter the API uses
+func parseFilters(pairs []string) (listing.ContentFilter, error) {
+	q := url.Values{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return listing.ContentFilter{}, fmt.Errorf("invalid filter %q, expected KEY=VALUE", pair)
+		}
+		q.Add(key, value)
+	}
+	return listing.ParseContentFilter(q)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Sreejit-Sengupto/internal/export"
+)
+
+func runExport(ctx context.Context, args []string) (err error) {
+	fs := newFlagSet("export")
+	format := fs.String("format", "ndjson", "output format: csv or ndjson")
+	out := fs.String("o", "-", "output file, - for stdout")
+	var filters multiFlag
+	fs.Var(&filters, "filter", "content listing filter as KEY=VALUE, repeatable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	exportFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	filter, err := parseFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	n, err := export.Write(ctx, repos.Contents, w, exportFormat, filter)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d items\n", n)
+	return nil
+}
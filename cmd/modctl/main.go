@@ -0,0 +1,190 @@
+// Command modctl operates the moderation system from a terminal: submitting
+// and inspecting content, overriding and re-moderating, inspecting queues,
+// exporting data and managing blocklists and policies.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/repository/postgres"
+	"github.com/Sreejit-Sengupto/internal/repository/sqlite"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/joho/godotenv"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// commands is filled in init since the handlers print their usage from it
+var commands []command
+
+func init() {
+	commands = []command{
+		{"submit", "-text TEXT [-image URL] [-video URL] [-tenant ID] [-author ID]", "submit content for moderation", runSubmit},
+		{"show", "ID", "show content with its results, events and audits", runShow},
+		{"override", "ID -final STATUS -reason TEXT [-text STATUS] [-image STATUS] [-video STATUS]", "override statuses as a reviewer", runOverride},
+		{"remoderate", "(-id ID ... | -filter KEY=VALUE ...) -reason TEXT [-limit N] [-dry-run]", "send content through moderation again", runRemoderate},
+		{"queues", "", "show task counts per queue", runQueues},
+		{"tasks", "QUEUE [-state STATE] [-limit N]", "list tasks in a queue", runTasks},
+		{"task", "QUEUE TASK_ID", "show a task with its payload", runTask},
+		{"replay", "QUEUE (TASK_ID | -all-archived | -all-retry)", "run archived, retrying or scheduled tasks now", runReplay},
+		{"export", "[-format csv|ndjson] [-o FILE] [-filter KEY=VALUE ...]", "export content, results, events and audits", runExport},
+		{"blocklist", "(list [-tenant ID] | add -term TERM [-tenant ID] [-category CAT] [-reason TEXT] | remove ID)", "manage blocklisted terms", runBlocklist},
+		{"policy", "(list [-tenant ID] | show ID | add -media TXT|IMG -version V -file PATH [-tenant ID] [-activate] | activate ID | deactivate ID)", "manage moderation policies", runPolicy},
+	}
+}
+
+// cfg is the loaded configuration, used by commands that open extra connections
+var cfg *config.Config
+
+// repos is how commands read and write moderation data
+var repos *repository.Repositories
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: modctl [-config file] COMMAND [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-11s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run 'modctl COMMAND -h' for a command's arguments.")
+}
+
+func main() {
+	// Load .env file, quietly since most operators rely on the environment
+	godotenv.Load()
+
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "modctl: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	cfg, err = config.Load(*configPath, config.ModeCLI)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Logs go to stderr so stdout stays clean for output and exports
+	logging.InitLoggerTo(os.Stderr, cfg.Log)
+
+	database.ConnectDatabase(cfg.Database)
+	if cfg.Database.Driver == config.DriverSQLite {
+		repos = sqlite.New(database.DB)
+	} else {
+		repos = postgres.New(database.DB)
+	}
+
+	// A memory-backed server keeps its queue to itself, so commands that
+	// enqueue tasks fail with workerClient.ErrDetached
+	if cfg.Queue.Backend == config.QueueMemory {
+		workerClient.InitDetachedClient()
+	} else {
+		workerClient.InitClient(cfg.Redis)
+	}
+	defer workerClient.CloseClient()
+
+	stream.InitStream(cfg.Redis)
+	defer stream.CloseStream()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "modctl %s: %v\n", cmd.name, err)
+		stop()
+		workerClient.CloseClient()
+		stream.CloseStream()
+		os.Exit(1)
+	}
+}
+
+// requireQueue stops commands that enqueue tasks before they write anything,
+// since a memory-backed server's queue can't be reached from here
+func requireQueue() error {
+	if cfg.Queue.Backend == config.QueueMemory {
+		return workerClient.ErrDetached
+	}
+	return nil
+}
+
+// newFlagSet returns a flag set for the named command that prints its arguments on -h
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("modctl "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		for _, c := range commands {
+			if c.name == name {
+				fmt.Fprintf(fs.Output(), "Usage: modctl %s %s\n\n%s\n\n", c.name, c.args, c.summary)
+			}
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseInterleaved parses flags that may come before or after positional arguments
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// multiFlag collects a flag given several times
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
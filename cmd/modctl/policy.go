@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+)
+
+func runPolicy(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected list, show, add, activate or deactivate")
+	}
+	// show, activate and deactivate take a policy ID
+	parseID := func() (uuid.UUID, error) {
+		if len(args) != 2 {
+			return uuid.Nil, errors.New("expected one policy ID")
+		}
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("invalid policy ID: %v", err)
+		}
+		return id, nil
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("policy")
+		tenantID := fs.String("tenant", "", "only list this tenant's policies")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		policies, err := repos.Policies.List(ctx, *tenantID)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTENANT\tMEDIA\tVERSION\tACTIVE\tCREATED")
+		for _, p := range policies {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", p.ID, p.TenantID, p.MediaType, p.Version, p.Active, p.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return tw.Flush()
+
+	case "show":
+		id, err := parseID()
+		if err != nil {
+			return err
+		}
+		policy, err := repos.Policies.Get(ctx, id)
+		if err != nil {
+			return policyError(err)
+		}
+		return printJSON(policy)
+
+	case "add":
+		fs := newFlagSet("policy")
+		tenantID := fs.String("tenant", tenant.Default, "tenant ID")
+		media := fs.String("media", "", "media type the policy applies to: TXT or IMG (required)")
+		version := fs.String("version", "", "version recorded on results moderated under the policy (required)")
+		file := fs.String("file", "", "file holding the system instruction, - for stdin (required)")
+		activate := fs.Bool("activate", false, "make the policy active right away")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		mediaType := models.MediaType(strings.ToUpper(*media))
+		if mediaType != models.Txt && mediaType != models.Img {
+			return fmt.Errorf("invalid media type %q, policies apply to TXT or IMG", *media)
+		}
+		if *version == "" || *file == "" {
+			return errors.New("-version and -file are required")
+		}
+
+		var instruction []byte
+		var err error
+		if *file == "-" {
+			instruction, err = io.ReadAll(os.Stdin)
+		} else {
+			instruction, err = os.ReadFile(*file)
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(instruction)) == "" {
+			return errors.New("instruction is empty")
+		}
+
+		policy := models.Policy{
+			TenantID:    *tenantID,
+			MediaType:   mediaType,
+			Version:     *version,
+			Instruction: strings.TrimSpace(string(instruction)),
+		}
+		if err := repos.Policies.Create(ctx, &policy); err != nil {
+			return err
+		}
+		if *activate {
+			if policy, err = repos.Policies.Activate(ctx, policy.ID); err != nil {
+				return err
+			}
+		}
+		return printJSON(policy)
+
+	case "activate":
+		id, err := parseID()
+		if err != nil {
+			return err
+		}
+		policy, err := repos.Policies.Activate(ctx, id)
+		if err != nil {
+			return policyError(err)
+		}
+		return printJSON(policy)
+
+	case "deactivate":
+		id, err := parseID()
+		if err != nil {
+			return err
+		}
+		if err := repos.Policies.Deactivate(ctx, id); err != nil {
+			return policyError(err)
+		}
+		fmt.Printf("%s\tdeactivated\n", id)
+		return nil
+	}
+
+	return fmt.Errorf("unknown policy command %q", args[0])
+}
+
+func policyError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return errors.New("policy not found")
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/hibiken/asynq"
+)
+
+// newInspector connects an asynq inspector to the configured Redis
+func newInspector() (*asynq.Inspector, error) {
+	if cfg.Queue.Backend != config.QueueRedis {
+		return nil, fmt.Errorf("queues can only be inspected with the redis backend, got %s", cfg.Queue.Backend)
+	}
+	opt, err := asynq.ParseRedisURI(cfg.Redis.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REDIS_URL: %v", err)
+	}
+	return asynq.NewInspector(opt), nil
+}
+
+func runQueues(ctx context.Context, args []string) error {
+	fs := newFlagSet("queues")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	inspector, err := newInspector()
+	if err != nil {
+		return err
+	}
+	defer inspector.Close()
+
+	queues, err := inspector.Queues()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tPAUSED\tACTIVE\tPENDING\tSCHEDULED\tRETRY\tARCHIVED\tCOMPLETED\tLATENCY")
+	for _, queue := range queues {
+		info, err := inspector.GetQueueInfo(queue)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			queue, info.Paused, info.Active, info.Pending, info.Scheduled,
+			info.Retry, info.Archived, info.Completed, info.Latency.Round(time.Millisecond))
+	}
+	return tw.Flush()
+}
+
+func runTasks(ctx context.Context, args []string) error {
+	fs := newFlagSet("tasks")
+	state := fs.String("state", "archived", "task state: active, pending, scheduled, retry, archived or completed")
+	limit := fs.Int("limit", 20, "maximum tasks to list")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("expected one queue name")
+	}
+	queue := positional[0]
+
+	inspector, err := newInspector()
+	if err != nil {
+		return err
+	}
+	defer inspector.Close()
+
+	opts := []asynq.ListOption{asynq.PageSize(*limit)}
+	var list []*asynq.TaskInfo
+	switch *state {
+	case "active":
+		list, err = inspector.ListActiveTasks(queue, opts...)
+	case "pending":
+		list, err = inspector.ListPendingTasks(queue, opts...)
+	case "scheduled":
+		list, err = inspector.ListScheduledTasks(queue, opts...)
+	case "retry":
+		list, err = inspector.ListRetryTasks(queue, opts...)
+	case "archived":
+		list, err = inspector.ListArchivedTasks(queue, opts...)
+	case "completed":
+		list, err = inspector.ListCompletedTasks(queue, opts...)
+	default:
+		return fmt.Errorf("invalid state %q", *state)
+	}
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tRETRIED\tLAST FAILED\tLAST ERROR")
+	for _, t := range list {
+		lastFailed := "-"
+		if !t.LastFailedAt.IsZero() {
+			lastFailed = t.LastFailedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%s\t%s\n", t.ID, t.Type, t.Retried, t.MaxRetry, lastFailed, t.LastErr)
+	}
+	return tw.Flush()
+}
+
+// taskView is the JSON shape of a single task, with its payload decoded when it is JSON
+type taskView struct {
+	ID            string      `json:"id"`
+	Queue         string      `json:"queue"`
+	Type          string      `json:"type"`
+	State         string      `json:"state"`
+	Payload       interface{} `json:"payload"`
+	Retried       int         `json:"retried"`
+	MaxRetry      int         `json:"maxRetry"`
+	LastErr       string      `json:"lastErr,omitempty"`
+	LastFailedAt  *time.Time  `json:"lastFailedAt,omitempty"`
+	NextProcessAt *time.Time  `json:"nextProcessAt,omitempty"`
+}
+
+func runTask(ctx context.Context, args []string) error {
+	fs := newFlagSet("task")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errors.New("expected a queue name and a task ID")
+	}
+
+	inspector, err := newInspector()
+	if err != nil {
+		return err
+	}
+	defer inspector.Close()
+
+	info, err := inspector.GetTaskInfo(positional[0], positional[1])
+	if err != nil {
+		return err
+	}
+
+	view := taskView{
+		ID:       info.ID,
+		Queue:    info.Queue,
+		Type:     info.Type,
+		State:    info.State.String(),
+		Payload:  json.RawMessage(info.Payload),
+		Retried:  info.Retried,
+		MaxRetry: info.MaxRetry,
+		LastErr:  info.LastErr,
+	}
+	if !json.Valid(info.Payload) {
+		view.Payload = string(info.Payload)
+	}
+	if !info.LastFailedAt.IsZero() {
+		view.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		view.NextProcessAt = &info.NextProcessAt
+	}
+	return printJSON(view)
+}
+
+func runReplay(ctx context.Context, args []string) error {
+	fs := newFlagSet("replay")
+	allArchived := fs.Bool("all-archived", false, "replay every archived task in the queue")
+	allRetry := fs.Bool("all-retry", false, "run every task waiting to retry in the queue now")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	all := *allArchived || *allRetry
+	if len(positional) != 1 && !(len(positional) == 2 && !all) {
+		return errors.New("expected a queue name and either a task ID, -all-archived or -all-retry")
+	}
+	if len(positional) == 1 && !all {
+		return errors.New("give a task ID, -all-archived or -all-retry")
+	}
+	queue := positional[0]
+
+	inspector, err := newInspector()
+	if err != nil {
+		return err
+	}
+	defer inspector.Close()
+
+	if !all {
+		if err := inspector.RunTask(queue, positional[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%s\tqueued\n", positional[1])
+		return nil
+	}
+
+	if *allArchived {
+		n, err := inspector.RunAllArchivedTasks(queue)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d archived tasks queued\n", n)
+	}
+	if *allRetry {
+		n, err := inspector.RunAllRetryTasks(queue)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d retry tasks queued\n", n)
+	}
+	return nil
+}
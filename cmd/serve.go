@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/Sreejit-Sengupto/api/routes"
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/export"
+	"github.com/Sreejit-Sengupto/internal/health"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metering"
+	"github.com/Sreejit-Sengupto/internal/metrics"
+	"github.com/Sreejit-Sengupto/internal/migrations"
+	"github.com/Sreejit-Sengupto/internal/provider"
+	"github.com/Sreejit-Sengupto/internal/queue"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/internal/queue/workers/image"
+	"github.com/Sreejit-Sengupto/internal/queue/workers/text"
+	"github.com/Sreejit-Sengupto/internal/ratelimit"
+	"github.com/Sreejit-Sengupto/internal/repository/postgres"
+	"github.com/Sreejit-Sengupto/internal/repository/sqlite"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/utils/cors"
+	"github.com/Sreejit-Sengupto/utils/gemini"
+	"github.com/Sreejit-Sengupto/utils/imagekit"
+	"github.com/gorilla/mux"
+)
+
+func connectDatabase(cfg *config.Config) {
+	database.ConnectDatabase(cfg.Database)
+	health.Register(cfg.Database.Driver, true, database.Ping)
+}
+
+// serve runs the API, the worker server or both until SIGINT/SIGTERM, only
+// initializing the dependencies the mode uses
+func serve(mode config.Mode, cfg *config.Config) {
+	connectDatabase(cfg)
+	if cfg.Database.RunMigrations {
+		// Instances starting together take turns through the migration lock
+		sqlDB, err := database.DB.DB()
+		if err != nil {
+			log.Fatalf("Failed to get DB object: %v", err)
+		}
+		dialect := migrations.Dialect(database.DB.Dialector.Name())
+		if err := migrations.Up(context.Background(), sqlDB, dialect); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	}
+
+	// Handlers and workers reach the database through the repositories
+	repos := postgres.New(database.DB)
+	if cfg.Database.Driver == config.DriverSQLite {
+		repos = sqlite.New(database.DB)
+	}
+
+	// Model prices and budgets, for workers recording calls and the usage API
+	metering.Init(cfg.Metering)
+
+	// Storage backend for exports, written by workers and downloaded through the API
+	export.InitStorage(cfg.Export)
+
+	// Both the API and the workers enqueue tasks
+	if cfg.Queue.Backend == config.QueueMemory {
+		// Tasks stay in this process, which also runs the workers
+		queue.InitInProcess(cfg.Worker)
+	} else {
+		workerClient.InitClient(cfg.Redis)
+		health.Register("redis_queue_client", true, func(ctx context.Context) error {
+			return workerClient.Ping()
+		})
+
+		// Queue depth gauges for /metrics
+		metrics.InitQueueMetrics(cfg.Redis, cfg.Worker.QueueNames())
+		defer metrics.CloseQueueMetrics()
+	}
+	defer workerClient.CloseClient()
+
+	// Redis pub/sub for the real-time event stream, in-process without Redis
+	stream.InitStream(cfg.Redis)
+	defer stream.CloseStream()
+	if cfg.Redis.URL != "" {
+		health.Register("redis_event_stream", false, stream.Ping)
+	}
+
+	var limiter *ratelimit.Limiter
+	if mode.RunsAPI() {
+		// init imagekit
+		imagekit.InitImageKit(cfg.ImageKit)
+
+		// Rate limits and quotas, shared through Redis when there is one
+		if cfg.RateLimit.Enabled {
+			limiter = ratelimit.New(cfg.RateLimit, cfg.Redis)
+			defer limiter.Close()
+			if cfg.Redis.URL != "" {
+				health.Register("redis_rate_limiter", false, limiter.Ping)
+			}
+		}
+	}
+
+	// Channel to signal worker server shutdown
+	workerShutdown := make(chan struct{})
+
+	// WaitGroup to track goroutines
+	var wg sync.WaitGroup
+
+	if mode.RunsWorker() {
+		initGemini(cfg)
+
+		// Start worker server
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queue.StartWorkerServer(cfg.Worker, cfg.Redis, repos, workerShutdown)
+		}()
+		// The in-process queue is the only way tasks get processed, so it gates readiness
+		if cfg.Queue.Backend == config.QueueMemory {
+			health.Register("in_process_queue", true, func(ctx context.Context) error {
+				return queue.Ping()
+			})
+		} else {
+			health.Register("redis_queue_server", false, func(ctx context.Context) error {
+				return queue.Ping()
+			})
+		}
+	}
+
+	// Init router
+	r := mux.NewRouter()
+
+	// Worker-only processes still serve metrics and health for scraping and probes
+	addr := cfg.Worker.MetricsAddr
+	if mode.RunsAPI() {
+		addr = cfg.Server.Addr
+		routes.RegisterRoutes(r, handlers.New(repos, handlers.Options{
+			IdempotencyRetention: cfg.Server.IdempotencyRetention.Std(),
+			MaxIdempotentBody:    cfg.Server.MaxIdempotentBody,
+			APIKeys:              cfg.Server.APIKeys,
+			Limiter:              limiter,
+		}))
+	} else {
+		routes.RegisterOpsRoutes(r)
+	}
+
+	// Request counts and latencies per route template
+	r.Use(metrics.Middleware)
+
+	// Origins allowed to call the API from a browser
+	cors.InitCORS(cfg.Server.CORSOrigins)
+
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: cors.Middleware(logging.Middleware(r)),
+	}
+
+	// Start HTTP server in goroutine
+	go func() {
+		slog.Info("server started", "addr", addr, "mode", mode)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Setup signal handling for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// Wait for shutdown signal
+	sig := <-quit
+	slog.Info("received signal, initiating graceful shutdown", "signal", sig.String())
+
+	// Create a context with timeout for shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
+	defer cancel()
+
+	// Shutdown HTTP server
+	slog.Info("shutting down HTTP server")
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("HTTP server shutdown error", "error", err)
+	}
+
+	// Signal worker server to shutdown
+	if mode.RunsWorker() {
+		slog.Info("shutting down worker server")
+	}
+	close(workerShutdown)
+
+	// Wait for worker to finish with timeout
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("all workers shut down gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown timed out")
+	}
+
+	slog.Info("server exited")
+}
+
+// initGemini sets up the moderation provider, which only workers call
+func initGemini(cfg *config.Config) {
+	// Init gemini (uses context.Background() internally for long-lived client)
+	if err := gemini.InitGemini(cfg.Gemini); err != nil {
+		log.Fatalf("Failed to initialize Gemini: %v", err)
+	}
+	// Circuit breakers and the fallback chain around the model calls
+	provider.Init(cfg.Provider)
+	health.RegisterCheck(&health.Check{
+		Name: "moderation_provider",
+		Run: func(ctx context.Context) error {
+			for _, model := range append([]string{text.Model, image.Model}, provider.FallbackModels()...) {
+				if err := gemini.Ping(ctx, model); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// Model lookups go to the Gemini API, so don't repeat them on every probe
+		CacheFor: time.Minute,
+	})
+	// Tasks fall back while a circuit is open, so it only degrades the service
+	health.Register("model_circuits", false, provider.CheckCircuits)
+}
@@ -0,0 +1,157 @@
+// Package breaker implements circuit breakers, which stop calling a
+// dependency that keeps failing so callers can fall back at once instead of
+// waiting on it. A breaker opens after a number of consecutive failures,
+// refuses calls until its timeout has passed, then lets a single call
+// through: the circuit closes again when that call succeeds and reopens when
+// it fails.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/metrics"
+)
+
+// 'closed', 'half_open', 'open'
+type State string
+
+const (
+	Closed State = "closed"
+	// HalfOpen lets a single call through to test the dependency
+	HalfOpen State = "half_open"
+	Open     State = "open"
+)
+
+// Outcome is what a call let through tells about the dependency
+type Outcome int
+
+const (
+	// Success closes the circuit and resets the failure count
+	Success Outcome = iota
+	// Failure counts towards opening the circuit
+	Failure
+	// Neutral is a call that said nothing about the dependency's health, such
+	// as one refused because of the caller's request; it neither resets nor
+	// trips the breaker
+	Neutral
+)
+
+// ErrOpen is returned by Allow while the circuit refuses calls
+var ErrOpen = errors.New("circuit open")
+
+// gaugeValues are what the state gauge reports for each state
+var gaugeValues = map[State]float64{Closed: 0, HalfOpen: 1, Open: 2}
+
+// Breaker guards one dependency. Callers ask Allow before every call and
+// report its outcome to Done with the token Allow returned.
+type Breaker struct {
+	name      string
+	threshold int
+	timeout   time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// generation counts state changes, so outcomes of calls let through in
+	// an earlier state can be told apart
+	generation uint64
+	// probing is set while the call let through in HalfOpen is running
+	probing bool
+}
+
+// Token ties the outcome reported to Done to the call Allow let through
+type Token struct {
+	generation uint64
+	probe      bool
+}
+
+// New returns a closed breaker that opens after threshold consecutive
+// failures and stays open for timeout. Its state is reported by the
+// circuit_breaker_state gauge under name.
+func New(name string, threshold int, timeout time.Duration) *Breaker {
+	b := &Breaker{name: name, threshold: threshold, timeout: timeout, state: Closed}
+	metrics.CircuitState.WithLabelValues(name).Set(gaugeValues[Closed])
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow returns ErrOpen when the call must not be made. Every nil return
+// must be followed by a call to Done with the token.
+func (b *Breaker) Allow() (Token, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.timeout {
+			return Token{}, ErrOpen
+		}
+		b.setState(HalfOpen)
+	case HalfOpen:
+		if b.probing {
+			return Token{}, ErrOpen
+		}
+	default:
+		return Token{generation: b.generation}, nil
+	}
+	b.probing = true
+	return Token{generation: b.generation, probe: true}, nil
+}
+
+// Done records the outcome of the call Allow gave token for. Only failures of
+// the dependency itself should count, errors caused by the caller's request
+// are Neutral. Outcomes of calls let through before the last state change are
+// ignored, so a slow call started while closed can't close a breaker that
+// has opened since; only the probe decides a half-open breaker, and a Neutral
+// probe leaves it half-open for the next call to decide.
+func (b *Breaker) Done(token Token, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if token.generation != b.generation {
+		return
+	}
+	if token.probe {
+		b.probing = false
+	}
+
+	switch outcome {
+	case Neutral:
+		return
+	case Success:
+		b.failures = 0
+		b.setState(Closed)
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(Open)
+	}
+}
+
+// State returns the breaker's state, Open until its timeout has passed even
+// though the next Allow would then let a call through
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState moves the breaker to state; callers hold b.mu
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	b.generation++
+	metrics.CircuitState.WithLabelValues(b.name).Set(gaugeValues[state])
+	metrics.CircuitTransitions.WithLabelValues(b.name, string(state)).Inc()
+}
@@ -0,0 +1,79 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func allow(t *testing.T, b *Breaker) Token {
+	t.Helper()
+	token, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow returned %v in state %s", err, b.State())
+	}
+	return token
+}
+
+// TestNeutralOutcomesDontResetFailures interleaves refused requests with
+// outages: they must neither reset the failure count nor trip the breaker
+func TestNeutralOutcomesDontResetFailures(t *testing.T) {
+	b := New("test-neutral", 3, time.Hour)
+
+	for range 5 {
+		b.Done(allow(t, b), Neutral)
+	}
+	if b.State() != Closed {
+		t.Fatalf("got state %s after neutral outcomes, want %s", b.State(), Closed)
+	}
+
+	b.Done(allow(t, b), Failure)
+	b.Done(allow(t, b), Neutral)
+	b.Done(allow(t, b), Failure)
+	b.Done(allow(t, b), Neutral)
+	if b.State() != Closed {
+		t.Fatalf("got state %s after two failures, want %s", b.State(), Closed)
+	}
+	b.Done(allow(t, b), Failure)
+	if b.State() != Open {
+		t.Fatalf("got state %s after three failures between neutral outcomes, want %s", b.State(), Open)
+	}
+	if _, err := b.Allow(); err != ErrOpen {
+		t.Fatalf("got %v from an open breaker, want ErrOpen", err)
+	}
+}
+
+// TestNeutralProbeLeavesBreakerHalfOpen checks that a neutral probe lets the
+// next call decide, and that only the probe does
+func TestNeutralProbeLeavesBreakerHalfOpen(t *testing.T) {
+	b := New("test-neutral-probe", 1, time.Millisecond)
+
+	b.Done(allow(t, b), Failure)
+	time.Sleep(2 * time.Millisecond)
+
+	probe := allow(t, b)
+	if _, err := b.Allow(); err != ErrOpen {
+		t.Fatalf("got %v while the probe runs, want ErrOpen", err)
+	}
+	b.Done(probe, Neutral)
+	if b.State() != HalfOpen {
+		t.Fatalf("got state %s after a neutral probe, want %s", b.State(), HalfOpen)
+	}
+
+	b.Done(allow(t, b), Success)
+	if b.State() != Closed {
+		t.Fatalf("got state %s after a successful probe, want %s", b.State(), Closed)
+	}
+}
+
+// TestStaleOutcomeIsIgnored checks that a call let through while closed
+// can't close a breaker that opened while it ran
+func TestStaleOutcomeIsIgnored(t *testing.T) {
+	b := New("test-stale", 1, time.Hour)
+
+	slow := allow(t, b)
+	b.Done(allow(t, b), Failure)
+	b.Done(slow, Success)
+	if b.State() != Open {
+		t.Fatalf("got state %s after a stale success, want %s", b.State(), Open)
+	}
+}
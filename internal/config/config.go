@@ -0,0 +1,464 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Config holds every setting the service reads at startup. Values come from
+// the defaults below, then the JSON config file, then environment variables.
+type Config struct {
+	Server    ServerConfig    `json:"server"`
+	Database  DatabaseConfig  `json:"database"`
+	Redis     RedisConfig     `json:"redis"`
+	Queue     QueueConfig     `json:"queue"`
+	Worker    WorkerConfig    `json:"worker"`
+	Gemini    GeminiConfig    `json:"gemini"`
+	Provider  ProviderConfig  `json:"provider"`
+	ImageKit  ImageKitConfig  `json:"imagekit"`
+	Export    ExportConfig    `json:"export"`
+	RateLimit RateLimitConfig `json:"rateLimit"`
+	Metering  MeteringConfig  `json:"metering"`
+	Log       LogConfig       `json:"log"`
+	Tracing   TracingConfig   `json:"tracing"`
+}
+
+type ServerConfig struct {
+	// Addr is the HTTP listen address
+	Addr string `json:"addr" env:"HTTP_ADDR"`
+	// ShutdownTimeout bounds the graceful shutdown of the HTTP and worker servers
+	ShutdownTimeout Duration `json:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	// CORSOrigins are the browser origins allowed to call the API
+	CORSOrigins []string `json:"corsOrigins" env:"CORS_ORIGINS"`
+	// IdempotencyRetention is how long the response to a request with an
+	// Idempotency-Key is replayed for repeats of it
+	IdempotencyRetention Duration `json:"idempotencyRetention" env:"IDEMPOTENCY_RETENTION"`
+	// MaxIdempotentBody caps the bytes of a request body read to fingerprint
+	// a request with an Idempotency-Key
+	MaxIdempotentBody int `json:"maxIdempotentBody" env:"MAX_IDEMPOTENT_BODY"`
+	// APIKeys maps each registered API key to the tenant it belongs to. When
+	// set, every API request needs one of them and is scoped to its tenant;
+	// otherwise requests name their tenant in X-Tenant-ID.
+	APIKeys map[string]string `json:"apiKeys" env:"API_KEYS"`
+}
+
+// Database drivers, named like the GORM dialects
+const (
+	DriverPostgres = "postgres"
+	// DriverSQLite stores everything in one local file, for development and tests
+	DriverSQLite = "sqlite"
+)
+
+type DatabaseConfig struct {
+	// Driver is postgres or sqlite
+	Driver string `json:"driver" env:"DB_DRIVER"`
+	// URL is a Postgres connection string, or the file path with sqlite
+	URL string `json:"url" env:"DATABASE_URL"`
+	// LogLevel is GORM's log level: silent, error, warn or info
+	LogLevel string `json:"logLevel" env:"DB_LOG_LEVEL"`
+	// RunMigrations applies pending migrations at startup
+	RunMigrations bool `json:"runMigrations" env:"RUN_MIGRATION"`
+}
+
+type RedisConfig struct {
+	// URL is used by the task queue and the event stream; rediss:// enables TLS.
+	// Without it the event stream only reaches subscribers of the same process
+	URL string `json:"url" env:"REDIS_URL"`
+}
+
+// Queue backends
+const (
+	// QueueRedis keeps tasks in Redis through asynq, shared by every process
+	QueueRedis = "redis"
+	// QueueMemory keeps tasks in the memory of a single all-mode process
+	QueueMemory = "memory"
+)
+
+type QueueConfig struct {
+	// Backend is redis or memory; memory runs the whole flow without Redis
+	// but loses pending tasks on restart
+	Backend string `json:"backend" env:"QUEUE_BACKEND"`
+}
+
+type WorkerConfig struct {
+	Concurrency int `json:"concurrency" env:"WORKER_CONCURRENCY"`
+	// MetricsAddr serves /metrics, /healthz and /readyz in serve-worker mode
+	MetricsAddr string `json:"metricsAddr" env:"WORKER_METRICS_ADDR"`
+	// Queues maps each queue to its priority weight
+	Queues map[string]int `json:"queues" env:"WORKER_QUEUES"`
+}
+
+type GeminiConfig struct {
+	APIKey string `json:"apiKey" env:"GEMINI_API_KEY"`
+}
+
+// Fallback steps that aren't model names
+const (
+	// FallbackRules moderates text with local heuristics, without a model
+	FallbackRules = "rules"
+	// FallbackHold leaves the modality PENDING for human review
+	FallbackHold = "hold"
+)
+
+type ProviderConfig struct {
+	// FailureThreshold is how many calls to a model may fail in a row before
+	// its circuit opens and calls to it are refused
+	FailureThreshold int `json:"failureThreshold" env:"BREAKER_FAILURE_THRESHOLD"`
+	// OpenTimeout is how long an open circuit refuses calls before one is let
+	// through to test whether the model has recovered
+	OpenTimeout Duration `json:"openTimeout" env:"BREAKER_OPEN_TIMEOUT"`
+	// Fallback is tried in order when a modality's model fails or its circuit
+	// is open: other model names, rules or hold. When it is empty or runs
+	// out, the task fails
+	Fallback []string `json:"fallback" env:"MODERATION_FALLBACK"`
+}
+
+type ImageKitConfig struct {
+	PrivateKey string `json:"privateKey" env:"IMAGEKIT_PRIVATE_KEY"`
+}
+
+type ExportConfig struct {
+	// Dir is where background exports are written
+	Dir string `json:"dir" env:"EXPORT_DIR"`
+}
+
+// Quota scopes, the caller submissions are counted against
+const (
+	QuotaTenant = "tenant"
+	QuotaClient = "client"
+)
+
+type RateLimitConfig struct {
+	// Enabled turns on rate limiting and quotas for the API. They are shared
+	// through Redis when redis.url is set, and kept per process otherwise
+	Enabled bool `json:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// RequestsPerSecond is how fast a client's token bucket refills
+	RequestsPerSecond int `json:"requestsPerSecond" env:"RATE_LIMIT_RPS"`
+	// Burst is the size of the bucket, the requests a client may make at once
+	Burst int `json:"burst" env:"RATE_LIMIT_BURST"`
+	// TrustProxy identifies clients without an API key by the address in
+	// X-Forwarded-For instead of the connection's, for running behind a proxy
+	TrustProxy bool `json:"trustProxy" env:"RATE_LIMIT_TRUST_PROXY"`
+	// QuotaScope is tenant or client, what submissions are counted against
+	QuotaScope string `json:"quotaScope" env:"QUOTA_SCOPE"`
+	// DailyQuota and MonthlyQuota cap the content items submitted per UTC
+	// day and month; 0 means unlimited
+	DailyQuota   int `json:"dailyQuota" env:"DAILY_QUOTA"`
+	MonthlyQuota int `json:"monthlyQuota" env:"MONTHLY_QUOTA"`
+	// DailyQuotas and MonthlyQuotas override the quotas for single tenants
+	// or clients, keyed by their ID
+	DailyQuotas   map[string]int `json:"dailyQuotas" env:"DAILY_QUOTAS"`
+	MonthlyQuotas map[string]int `json:"monthlyQuotas" env:"MONTHLY_QUOTAS"`
+}
+
+// ModelPrice is what a model charges, in US dollars per million tokens
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion"`
+}
+
+type MeteringConfig struct {
+	// Prices maps each model to its token prices; calls to a model missing
+	// here are recorded without a cost
+	Prices map[string]ModelPrice `json:"prices"`
+	// MonthlyBudget is the model spend in US dollars a tenant may reach per
+	// UTC month before an alert is raised; 0 means no budget
+	MonthlyBudget float64 `json:"monthlyBudget" env:"MONTHLY_BUDGET"`
+	// MonthlyBudgets overrides the budget for single tenants
+	MonthlyBudgets map[string]float64 `json:"monthlyBudgets" env:"MONTHLY_BUDGETS"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level" env:"LOG_LEVEL"`
+	// Format is json or text
+	Format string `json:"format" env:"LOG_FORMAT"`
+}
+
+type TracingConfig struct {
+	// Endpoint of the OTLP/HTTP collector; tracing is disabled when empty
+	Endpoint    string `json:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `json:"serviceName" env:"OTEL_SERVICE_NAME"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:                 ":8080",
+			ShutdownTimeout:      Duration(30 * time.Second),
+			IdempotencyRetention: Duration(24 * time.Hour),
+			MaxIdempotentBody:    10 << 20,
+			CORSOrigins: []string{
+				"http://localhost:3000",
+				"http://localhost:3001",
+				"https://content-moderation-go-client.vercel.app",
+			},
+		},
+		Database: DatabaseConfig{
+			Driver:   DriverPostgres,
+			LogLevel: "warn",
+		},
+		Queue: QueueConfig{
+			Backend: QueueRedis,
+		},
+		Worker: WorkerConfig{
+			Concurrency: 10,
+			MetricsAddr: ":9091",
+			Queues: map[string]int{
+				"text":        5,
+				"image":       3,
+				"video":       1,
+				"aggregation": 1,
+				"webhook":     2,
+				"export":      1,
+			},
+		},
+		Provider: ProviderConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      Duration(30 * time.Second),
+			Fallback:         []string{FallbackHold},
+		},
+		Export: ExportConfig{
+			Dir: "exports",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 10,
+			Burst:             20,
+			QuotaScope:        QuotaTenant,
+		},
+		Metering: MeteringConfig{
+			// Gemini's paid tier list prices
+			Prices: map[string]ModelPrice{
+				"gemini-2.5-flash":       {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+				"gemini-3-flash-preview": {InputPerMillion: 0.50, OutputPerMillion: 3.00},
+			},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "content-moderation",
+		},
+	}
+}
+
+// Mode is the set of components a process runs, which decides the settings it needs
+type Mode string
+
+const (
+	// 'all', 'serve-api', 'serve-worker', 'migrate', 'cli'
+	ModeAll     Mode = "all"
+	ModeAPI     Mode = "serve-api"
+	ModeWorker  Mode = "serve-worker"
+	ModeMigrate Mode = "migrate"
+	// ModeCLI is the admin CLI, which needs the database and the queue but no servers
+	ModeCLI Mode = "cli"
+)
+
+// Modes lists the modes the server binary accepts
+var Modes = []Mode{ModeAll, ModeAPI, ModeWorker, ModeMigrate}
+
+// RunsAPI reports whether the mode serves the HTTP API
+func (m Mode) RunsAPI() bool {
+	return m == ModeAll || m == ModeAPI
+}
+
+// RunsWorker reports whether the mode processes queue tasks
+func (m Mode) RunsWorker() bool {
+	return m == ModeAll || m == ModeWorker
+}
+
+// Load builds the configuration from the defaults, the JSON file at path (if
+// path is not empty) and the environment, then validates it for mode
+func Load(path string, mode Mode) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(mode); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate reports every invalid setting at once. Settings for components
+// the mode doesn't run are not required.
+func (c *Config) Validate(mode Mode) error {
+	var errs []error
+
+	if mode.RunsAPI() && c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr (HTTP_ADDR) is required"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdownTimeout (SHUTDOWN_TIMEOUT) must be positive"))
+	}
+	if mode.RunsAPI() && c.Server.IdempotencyRetention <= 0 {
+		errs = append(errs, errors.New("server.idempotencyRetention (IDEMPOTENCY_RETENTION) must be positive"))
+	}
+	if mode.RunsAPI() && c.Server.MaxIdempotentBody <= 0 {
+		errs = append(errs, errors.New("server.maxIdempotentBody (MAX_IDEMPOTENT_BODY) must be positive"))
+	}
+	for key, tenant := range c.Server.APIKeys {
+		if key == "" || tenant == "" {
+			errs = append(errs, errors.New("server.apiKeys (API_KEYS) must map non-empty keys to tenants"))
+			break
+		}
+	}
+	if !slices.Contains([]string{DriverPostgres, DriverSQLite}, c.Database.Driver) {
+		errs = append(errs, fmt.Errorf("database.driver (DB_DRIVER) must be postgres or sqlite, got %q", c.Database.Driver))
+	}
+	if c.Database.URL == "" {
+		errs = append(errs, errors.New("database.url (DATABASE_URL) is required"))
+	}
+	if !slices.Contains([]string{"silent", "error", "warn", "info"}, c.Database.LogLevel) {
+		errs = append(errs, fmt.Errorf("database.logLevel (DB_LOG_LEVEL) must be silent, error, warn or info, got %q", c.Database.LogLevel))
+	}
+	if !slices.Contains([]string{QueueRedis, QueueMemory}, c.Queue.Backend) {
+		errs = append(errs, fmt.Errorf("queue.backend (QUEUE_BACKEND) must be redis or memory, got %q", c.Queue.Backend))
+	}
+	// The API and the workers only share an in-process queue inside one process
+	if c.Queue.Backend == QueueMemory && (mode == ModeAPI || mode == ModeWorker) {
+		errs = append(errs, fmt.Errorf("queue.backend (QUEUE_BACKEND) memory needs mode all, got %s", mode))
+	}
+	if mode != ModeMigrate && c.Queue.Backend == QueueRedis && c.Redis.URL == "" {
+		errs = append(errs, errors.New("redis.url (REDIS_URL) is required"))
+	}
+	if mode.RunsWorker() && c.Worker.Concurrency <= 0 {
+		errs = append(errs, errors.New("worker.concurrency (WORKER_CONCURRENCY) must be positive"))
+	}
+	if mode == ModeWorker && c.Worker.MetricsAddr == "" {
+		errs = append(errs, errors.New("worker.metricsAddr (WORKER_METRICS_ADDR) is required"))
+	}
+	for queue, weight := range c.Worker.Queues {
+		if weight <= 0 {
+			errs = append(errs, fmt.Errorf("worker.queues: weight of queue %q must be positive", queue))
+		}
+	}
+	// A queue left out would never be processed
+	for _, queue := range enqueuedQueues {
+		if _, ok := c.Worker.Queues[queue]; !ok {
+			errs = append(errs, fmt.Errorf("worker.queues (WORKER_QUEUES) must include the %s queue", queue))
+		}
+	}
+	if mode.RunsWorker() && c.Gemini.APIKey == "" {
+		errs = append(errs, errors.New("gemini.apiKey (GEMINI_API_KEY) is required"))
+	}
+	if mode.RunsWorker() {
+		errs = append(errs, c.Provider.validate()...)
+	}
+	if c.Export.Dir == "" {
+		errs = append(errs, errors.New("export.dir (EXPORT_DIR) is required"))
+	}
+	if mode.RunsAPI() && c.RateLimit.Enabled {
+		errs = append(errs, c.RateLimit.validate()...)
+	}
+	for model, price := range c.Metering.Prices {
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			errs = append(errs, fmt.Errorf("metering.prices: prices of model %q must not be negative", model))
+		}
+	}
+	if c.Metering.MonthlyBudget < 0 {
+		errs = append(errs, errors.New("metering.monthlyBudget (MONTHLY_BUDGET) must not be negative"))
+	}
+	for tenant, budget := range c.Metering.MonthlyBudgets {
+		if budget < 0 {
+			errs = append(errs, fmt.Errorf("metering.monthlyBudgets: budget of %q must not be negative", tenant))
+		}
+	}
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level) {
+		errs = append(errs, fmt.Errorf("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if !slices.Contains([]string{"json", "text"}, c.Log.Format) {
+		errs = append(errs, fmt.Errorf("log.format (LOG_FORMAT) must be json or text, got %q", c.Log.Format))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r RateLimitConfig) validate() []error {
+	var errs []error
+	if r.RequestsPerSecond <= 0 {
+		errs = append(errs, errors.New("rateLimit.requestsPerSecond (RATE_LIMIT_RPS) must be positive"))
+	}
+	if r.Burst <= 0 {
+		errs = append(errs, errors.New("rateLimit.burst (RATE_LIMIT_BURST) must be positive"))
+	}
+	if !slices.Contains([]string{QuotaTenant, QuotaClient}, r.QuotaScope) {
+		errs = append(errs, fmt.Errorf("rateLimit.quotaScope (QUOTA_SCOPE) must be tenant or client, got %q", r.QuotaScope))
+	}
+	if r.DailyQuota < 0 {
+		errs = append(errs, errors.New("rateLimit.dailyQuota (DAILY_QUOTA) must not be negative"))
+	}
+	if r.MonthlyQuota < 0 {
+		errs = append(errs, errors.New("rateLimit.monthlyQuota (MONTHLY_QUOTA) must not be negative"))
+	}
+	for id, quota := range r.DailyQuotas {
+		if quota < 0 {
+			errs = append(errs, fmt.Errorf("rateLimit.dailyQuotas: quota of %q must not be negative", id))
+		}
+	}
+	for id, quota := range r.MonthlyQuotas {
+		if quota < 0 {
+			errs = append(errs, fmt.Errorf("rateLimit.monthlyQuotas: quota of %q must not be negative", id))
+		}
+	}
+	return errs
+}
+
+func (p ProviderConfig) validate() []error {
+	var errs []error
+	if p.FailureThreshold <= 0 {
+		errs = append(errs, errors.New("provider.failureThreshold (BREAKER_FAILURE_THRESHOLD) must be positive"))
+	}
+	if p.OpenTimeout <= 0 {
+		errs = append(errs, errors.New("provider.openTimeout (BREAKER_OPEN_TIMEOUT) must be positive"))
+	}
+	for i, step := range p.Fallback {
+		switch {
+		case step == "":
+			errs = append(errs, errors.New("provider.fallback (MODERATION_FALLBACK) must not contain empty steps"))
+		case slices.Contains(p.Fallback[:i], step):
+			errs = append(errs, fmt.Errorf("provider.fallback (MODERATION_FALLBACK) lists %q twice", step))
+		case step == FallbackHold && i != len(p.Fallback)-1:
+			// Holding always succeeds, so nothing after it would run
+			errs = append(errs, errors.New("provider.fallback (MODERATION_FALLBACK): hold must be the last step"))
+		}
+	}
+	return errs
+}
+
+// enqueuedQueues are the queues tasks are enqueued to, as named in
+// internal/queue/tasks
+var enqueuedQueues = []string{"text", "image", "video", "aggregation", "webhook", "export"}
+
+// QueueNames returns the configured queues in a stable order
+func (w WorkerConfig) QueueNames() []string {
+	names := make([]string, 0, len(w.Queues))
+	for name := range w.Queues {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv overrides every field tagged with `env` whose variable is set
+func applyEnv(cfg *Config) error {
+	return walkEnv(reflect.ValueOf(cfg).Elem())
+}
+
+func walkEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walkEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Addr().Interface().(type) {
+	case *string:
+		field.SetString(raw)
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case *Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case *[]string:
+		// Comma-separated list
+		field.Set(reflect.ValueOf(splitList(raw)))
+	case *float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case *map[string]int:
+		// Comma-separated name=value pairs, e.g. text=5,image=3
+		m, err := splitPairs(raw, strconv.Atoi)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	case *map[string]string:
+		m, err := splitPairs(raw, func(s string) (string, error) {
+			return s, nil
+		})
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	case *map[string]float64:
+		m, err := splitPairs(raw, func(s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported config field type %s", field.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitPairs reads comma-separated name=value pairs, parsing each value
+func splitPairs[T any](raw string, parse func(string) (T, error)) (map[string]T, error) {
+	m := map[string]T{}
+	for _, pair := range splitList(raw) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=value, got %q", pair)
+		}
+		v, err := parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		m[strings.TrimSpace(name)] = v
+	}
+	return m, nil
+}
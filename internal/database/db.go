@@ -1,22 +1,34 @@
 package database
 
 import (
+	"context"
 	"log"
-	"os"
+	"time"
 
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	dsn := os.Getenv("DATABASE_URL")
+func ConnectDatabase(cfg config.DatabaseConfig) {
+	gormConfig := &gorm.Config{
+		Logger: logging.GormLogger(cfg.LogLevel),
+	}
+
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case config.DriverSQLite:
+		dialector = openSQLite(cfg.URL)
+		// SQLite compares timestamps as text, so they are all written in UTC
+		gormConfig.NowFunc = func() time.Time { return time.Now().UTC() }
+	default:
+		dialector = postgres.Open(cfg.URL)
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database %v", err)
 	}
@@ -32,3 +44,12 @@ func ConnectDatabase() {
 
 	DB = db
 }
+
+// Ping checks that the database is reachable
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
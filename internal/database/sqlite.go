@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteParams turn on foreign keys, which SQLite leaves off, and let the API
+// and the workers share the file: WAL lets reads run alongside the writer,
+// busy_timeout waits for the write lock instead of failing, and immediate
+// transactions take that lock up front so two of them can't deadlock
+const sqliteParams = "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
+// openSQLite opens the database file at path, creating it if needed
+func openSQLite(path string) gorm.Dialector {
+	dsn := path
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	if strings.Contains(dsn, "?") {
+		dsn += "&" + sqliteParams
+	} else {
+		dsn += "?" + sqliteParams
+	}
+
+	sqlDB, err := sql.Open(sqlite.DriverName, dsn)
+	if err != nil {
+		log.Fatalf("Failed to open SQLite database: %v", err)
+	}
+	return sqlite.Dialector{Conn: &utcPool{sqlDB}}
+}
+
+// utcPool passes every time argument to SQLite in UTC. Times are stored as
+// text with their offset, so a bound in another zone would compare wrongly.
+type utcPool struct {
+	*sql.DB
+}
+
+func (p *utcPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.DB.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.DB.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.DB.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+// BeginTx keeps transactions going through the conversion too
+func (p *utcPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	tx, err := p.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &utcTx{tx}, nil
+}
+
+// GetDBConn lets gorm.DB.DB return the underlying pool
+func (p *utcPool) GetDBConn() (*sql.DB, error) {
+	return p.DB, nil
+}
+
+type utcTx struct {
+	*sql.Tx
+}
+
+func (t *utcTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (t *utcTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (t *utcTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func utcArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				args[i] = v.UTC()
+			}
+		}
+	}
+	return args
+}
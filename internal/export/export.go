@@ -0,0 +1,155 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+// chunkSize is how many content rows are loaded, with their children, per query
+const chunkSize = 500
+
+var csvHeader = []string{
+	"content_id", "tenant_id", "author_id", "content_created_at", "text", "image", "video",
+	"text_status", "image_status", "video_status", "final_status",
+	"record_type", "record_id", "record_created_at",
+	"media_type", "status", "risk_score", "explanation",
+	"event_type", "payload", "action", "reason",
+}
+
+func ParseFormat(raw string) (models.ExportFormat, error) {
+	switch models.ExportFormat(strings.ToUpper(raw)) {
+	case "", models.CSV:
+		return models.CSV, nil
+	case models.NDJSON:
+		return models.NDJSON, nil
+	}
+	return "", fmt.Errorf("invalid format %q, expected csv or ndjson", raw)
+}
+
+func ContentType(format models.ExportFormat) string {
+	if format == models.NDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+func Extension(format models.ExportFormat) string {
+	if format == models.NDJSON {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// Write streams every content item matching the filter, together with its
+// moderation results, events and audits. It returns the number of content
+// items written.
+func Write(ctx context.Context, contents repository.ContentRepository, w io.Writer, format models.ExportFormat, filter listing.ContentFilter) (int64, error) {
+	var (
+		csvWriter *csv.Writer
+		encoder   *json.Encoder
+	)
+	if format == models.CSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(csvHeader); err != nil {
+			return 0, err
+		}
+	} else {
+		encoder = json.NewEncoder(w)
+	}
+
+	var written int64
+	page := listing.Page{Sort: "createdAt", Limit: chunkSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		chunk, err := contents.ListDetailed(ctx, filter, page)
+		if err != nil {
+			return written, fmt.Errorf("failed to fetch content: %v", err)
+		}
+		more := len(chunk) > chunkSize
+		if more {
+			chunk = chunk[:chunkSize]
+		}
+
+		for _, content := range chunk {
+			var err error
+			if csvWriter != nil {
+				err = writeCSV(csvWriter, content)
+			} else {
+				err = encoder.Encode(content)
+			}
+			if err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return written, err
+			}
+		}
+
+		if !more {
+			return written, nil
+		}
+		page.Cursor = page.CursorAt(chunk[len(chunk)-1])
+	}
+}
+
+// writeCSV flattens a content item into one row per child record, or a
+// single row when it has none yet
+func writeCSV(w *csv.Writer, c models.Content) error {
+	base := []string{
+		c.ID.String(), c.TenantID, c.AuthorID, formatTime(c.CreatedAt), c.Text, c.Image, c.Video,
+		string(c.TextStatus), string(c.ImageStatus), string(c.VideoStatus), string(c.FinalStatus),
+	}
+
+	row := func(recordType string, id uuid.UUID, createdAt time.Time, rest ...string) []string {
+		r := append(append([]string{}, base...), recordType, id.String(), formatTime(createdAt))
+		return append(r, rest...)
+	}
+
+	var rows [][]string
+	for _, res := range c.ModerationResult {
+		rows = append(rows, row("result", res.ID, res.CreatedAt,
+			string(res.MediaType), string(res.Status), strconv.FormatFloat(res.RiskScore, 'f', -1, 64), res.Explaination,
+			"", "", "", ""))
+	}
+	for _, ev := range c.ModerationEvents {
+		rows = append(rows, row("event", ev.ID, ev.CreatedAt,
+			"", "", "", "",
+			string(ev.EventType), string(ev.Payload), "", ""))
+	}
+	for _, a := range c.Audit {
+		rows = append(rows, row("audit", a.ID, a.CreatedAt,
+			"", "", "", "",
+			"", "", string(a.Action), a.Reason))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, append(append([]string{}, base...), make([]string, len(csvHeader)-len(base))...))
+	}
+
+	return w.WriteAll(rows)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+)
+
+// Storage is where background exports are written and later downloaded from
+type Storage interface {
+	Create(key string) (io.WriteCloser, error)
+	Open(key string) (io.ReadCloser, error)
+}
+
+// LocalStorage keeps exports as files under a directory
+type LocalStorage struct {
+	Dir string
+}
+
+func (s LocalStorage) Create(key string) (io.WriteCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func (s LocalStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid export key %q", key)
+	}
+	return filepath.Join(s.Dir, key), nil
+}
+
+var Store Storage
+
+func InitStorage(cfg config.ExportConfig) {
+	Store = LocalStorage{Dir: cfg.Dir}
+	slog.Info("export storage initialized", "dir", cfg.Dir)
+}
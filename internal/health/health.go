@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status of a single dependency or of the service as a whole
+type Status string
+
+const (
+	// 'OK', 'DEGRADED', 'DOWN'
+	OK       Status = "OK"
+	Degraded Status = "DEGRADED"
+	Down     Status = "DOWN"
+)
+
+// checkTimeout bounds each dependency check so one hung dependency can't stall the probe
+const checkTimeout = 2 * time.Second
+
+// Check verifies one dependency. A failing critical check marks the service
+// DOWN; a failing non-critical one only marks it DEGRADED.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+	// CacheFor reuses the last result for checks that are slow or cost
+	// something to run, such as calls to the moderation provider
+	CacheFor time.Duration
+
+	mu       sync.Mutex
+	last     Result
+	lastTime time.Time
+}
+
+type Result struct {
+	Status     Status    `json:"status"`
+	Critical   bool      `json:"critical"`
+	DurationMs float64   `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+type Report struct {
+	Status    Status            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+var (
+	mu     sync.RWMutex
+	checks []*Check
+)
+
+// Register adds a dependency check to the readiness report. Components call
+// it once they are initialized, so a process only checks what it uses.
+func Register(name string, critical bool, run func(ctx context.Context) error) {
+	RegisterCheck(&Check{Name: name, Critical: critical, Run: run})
+}
+
+func RegisterCheck(check *Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks = append(checks, check)
+}
+
+// Ready runs every registered check concurrently
+func Ready(ctx context.Context) Report {
+	mu.RLock()
+	registered := append([]*Check(nil), checks...)
+	mu.RUnlock()
+
+	results := make([]Result, len(registered))
+	var wg sync.WaitGroup
+	for i, check := range registered {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check.result(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    OK,
+		Checks:    make(map[string]Result, len(registered)),
+		CheckedAt: time.Now().UTC(),
+	}
+	for i, check := range registered {
+		result := results[i]
+		report.Checks[check.Name] = result
+		if result.Status == OK {
+			continue
+		}
+		if check.Critical {
+			report.Status = Down
+		} else if report.Status == OK {
+			report.Status = Degraded
+		}
+	}
+	return report
+}
+
+func (c *Check) result(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.CacheFor > 0 && !c.lastTime.IsZero() && time.Since(c.lastTime) < c.CacheFor {
+		return c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Run(ctx)
+	result := Result{
+		Status:     OK,
+		Critical:   c.Critical,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		result.Status = Down
+		result.Error = err.Error()
+	}
+
+	c.last = result
+	c.lastTime = time.Now()
+	return result
+}
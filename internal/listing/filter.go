@@ -0,0 +1,206 @@
+package listing
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"gorm.io/gorm"
+)
+
+// ContentFilter holds the content listing filters parsed from the query string
+type ContentFilter struct {
+	TenantID    string                 `json:"tenantId,omitempty"`
+	AuthorID    string                 `json:"authorId,omitempty"`
+	FinalStatus []models.ContentStatus `json:"finalStatus,omitempty"`
+	TextStatus  []models.ContentStatus `json:"textStatus,omitempty"`
+	ImageStatus []models.ContentStatus `json:"imageStatus,omitempty"`
+	VideoStatus []models.ContentStatus `json:"videoStatus,omitempty"`
+	HasImage    *bool                  `json:"hasImage,omitempty"`
+	HasVideo    *bool                  `json:"hasVideo,omitempty"`
+	CreatedFrom *time.Time             `json:"createdFrom,omitempty"`
+	CreatedTo   *time.Time             `json:"createdTo,omitempty"`
+	// CreatedBefore is the exclusive end a date-only createdTo stands for,
+	// the start of the following day
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+	MinRisk       *float64   `json:"minRisk,omitempty"`
+	MaxRisk       *float64   `json:"maxRisk,omitempty"`
+}
+
+func ParseContentFilter(q url.Values) (ContentFilter, error) {
+	var (
+		f   ContentFilter
+		err error
+	)
+
+	f.TenantID = q.Get("tenantId")
+	f.AuthorID = q.Get("authorId")
+
+	if f.FinalStatus, err = parseStatuses(q, "finalStatus"); err != nil {
+		return f, err
+	}
+	if f.TextStatus, err = parseStatuses(q, "textStatus"); err != nil {
+		return f, err
+	}
+	if f.ImageStatus, err = parseStatuses(q, "imageStatus"); err != nil {
+		return f, err
+	}
+	if f.VideoStatus, err = parseStatuses(q, "videoStatus"); err != nil {
+		return f, err
+	}
+	if f.HasImage, err = parseBool(q, "hasImage"); err != nil {
+		return f, err
+	}
+	if f.HasVideo, err = parseBool(q, "hasVideo"); err != nil {
+		return f, err
+	}
+	if f.CreatedFrom, _, err = parseTime(q, "createdFrom"); err != nil {
+		return f, err
+	}
+	createdTo, dateOnly, err := parseTime(q, "createdTo")
+	if err != nil {
+		return f, err
+	}
+	if dateOnly {
+		before := timerange.EndOfDay(*createdTo)
+		f.CreatedBefore = &before
+	} else {
+		f.CreatedTo = createdTo
+	}
+	if f.MinRisk, err = parseFloat(q, "minRisk"); err != nil {
+		return f, err
+	}
+	if f.MaxRisk, err = parseFloat(q, "maxRisk"); err != nil {
+		return f, err
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return f, fmt.Errorf("createdFrom must not be after createdTo")
+	}
+	if f.CreatedFrom != nil && f.CreatedBefore != nil && !f.CreatedFrom.Before(*f.CreatedBefore) {
+		return f, fmt.Errorf("createdFrom must not be after createdTo")
+	}
+	if f.MinRisk != nil && f.MaxRisk != nil && *f.MinRisk > *f.MaxRisk {
+		return f, fmt.Errorf("minRisk must not be greater than maxRisk")
+	}
+
+	return f, nil
+}
+
+// Apply adds the filter conditions to a query over the contents table
+func (f ContentFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.TenantID != "" {
+		db = db.Where("contents.tenant_id = ?", f.TenantID)
+	}
+	if f.AuthorID != "" {
+		db = db.Where("contents.author_id = ?", f.AuthorID)
+	}
+	if len(f.FinalStatus) > 0 {
+		db = db.Where("contents.final_status IN ?", f.FinalStatus)
+	}
+	if len(f.TextStatus) > 0 {
+		db = db.Where("contents.text_status IN ?", f.TextStatus)
+	}
+	if len(f.ImageStatus) > 0 {
+		db = db.Where("contents.image_status IN ?", f.ImageStatus)
+	}
+	if len(f.VideoStatus) > 0 {
+		db = db.Where("contents.video_status IN ?", f.VideoStatus)
+	}
+	if f.HasImage != nil {
+		if *f.HasImage {
+			db = db.Where("contents.image <> ''")
+		} else {
+			db = db.Where("contents.image = ''")
+		}
+	}
+	if f.HasVideo != nil {
+		if *f.HasVideo {
+			db = db.Where("contents.video <> ''")
+		} else {
+			db = db.Where("contents.video = ''")
+		}
+	}
+	if f.CreatedFrom != nil {
+		db = db.Where("contents.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		db = db.Where("contents.created_at <= ?", *f.CreatedTo)
+	}
+	if f.CreatedBefore != nil {
+		db = db.Where("contents.created_at < ?", *f.CreatedBefore)
+	}
+	// A content item matches the risk range when any of its modality results does
+	if f.MinRisk != nil || f.MaxRisk != nil {
+		minRisk, maxRisk := 0.0, 1.0
+		if f.MinRisk != nil {
+			minRisk = *f.MinRisk
+		}
+		if f.MaxRisk != nil {
+			maxRisk = *f.MaxRisk
+		}
+		db = db.Where("EXISTS (SELECT 1 FROM moderation_results mr WHERE mr.content_id = contents.id AND mr.risk_score BETWEEN ? AND ?)", minRisk, maxRisk)
+	}
+	return db
+}
+
+func parseStatuses(q url.Values, key string) ([]models.ContentStatus, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var statuses []models.ContentStatus
+	for _, s := range strings.Split(raw, ",") {
+		status := models.ContentStatus(strings.ToUpper(strings.TrimSpace(s)))
+		switch status {
+		case models.Pending, models.Approved, models.Rejected, models.Flagged:
+			statuses = append(statuses, status)
+		default:
+			return nil, fmt.Errorf("invalid %s %q", key, s)
+		}
+	}
+	return statuses, nil
+}
+
+func parseBool(q url.Values, key string) (*bool, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return &v, nil
+}
+
+func parseFloat(q url.Values, key string) (*float64, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return &v, nil
+}
+
+// parseTime accepts either a full RFC 3339 timestamp or a plain date, which
+// it reports as dateOnly
+func parseTime(q url.Values, key string) (t *time.Time, dateOnly bool, err error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, false, nil
+	}
+	parsed, dateOnly, err := timerange.ParseBound(raw, time.UTC)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s %q, expected RFC 3339 or YYYY-MM-DD", key, raw)
+	}
+	return &parsed, dateOnly, nil
+}
@@ -0,0 +1,40 @@
+package listing
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/timerange"
+)
+
+// TestDateOnlyUpperBoundCoversTheDay checks that listings and analytics agree
+// on a date-only upper bound: the day is included, up to the next midnight
+func TestDateOnlyUpperBoundCoversTheDay(t *testing.T) {
+	want := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	f, err := ParseContentFilter(url.Values{"createdTo": {"2024-05-31"}})
+	if err != nil {
+		t.Fatalf("failed to parse content filter: %v", err)
+	}
+	if f.CreatedBefore == nil || !f.CreatedBefore.Equal(want) {
+		t.Fatalf("got createdBefore %v, want %v", f.CreatedBefore, want)
+	}
+
+	rng, err := timerange.Parse(url.Values{"from": {"2024-05-01"}, "to": {"2024-05-31"}}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse range: %v", err)
+	}
+	if !rng.To.Equal(want) {
+		t.Fatalf("got to %v, want %v", rng.To, want)
+	}
+
+	stamp := time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)
+	rng, err = timerange.Parse(url.Values{"from": {"2024-05-01"}, "to": {stamp.Format(time.RFC3339)}}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse range: %v", err)
+	}
+	if !rng.To.Equal(stamp) {
+		t.Fatalf("got to %v for a timestamp, want it unchanged at %v", rng.To, stamp)
+	}
+}
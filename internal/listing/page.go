@@ -0,0 +1,138 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// sortColumns maps the accepted sort keys to their columns
+var sortColumns = map[string]string{
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// Cursor points at the last row of the previous page, under the sort and
+// order it was listed in
+type Cursor struct {
+	Value time.Time `json:"v"`
+	ID    uuid.UUID `json:"id"`
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(raw string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// Page holds the keyset pagination and sorting options
+type Page struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor *Cursor
+	Count  bool
+}
+
+func ParsePage(q url.Values) (Page, error) {
+	p := Page{Sort: "createdAt", Desc: true, Limit: DefaultLimit}
+
+	if sort := q.Get("sort"); sort != "" {
+		if _, ok := sortColumns[sort]; !ok {
+			return p, fmt.Errorf("invalid sort %q, expected createdAt or updatedAt", sort)
+		}
+		p.Sort = sort
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		p.Desc = false
+	default:
+		return p, fmt.Errorf("invalid order %q, expected asc or desc", q.Get("order"))
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		p.Limit = limit
+	}
+
+	if raw := q.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			return p, err
+		}
+		// The keyset only means something under the order that produced it
+		if cursor.Sort != p.Sort || cursor.Desc != p.Desc {
+			return p, fmt.Errorf("cursor was issued for a different sort or order")
+		}
+		p.Cursor = cursor
+	}
+
+	p.Count = q.Get("count") == "true"
+
+	return p, nil
+}
+
+// Apply adds the keyset condition, ordering and limit. One extra row is
+// fetched so Next can tell whether another page exists.
+func (p Page) Apply(db *gorm.DB) *gorm.DB {
+	column := "contents." + sortColumns[p.Sort]
+
+	direction, cmp := "ASC", ">"
+	if p.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if p.Cursor != nil {
+		db = db.Where(fmt.Sprintf("(%s, contents.id) %s (?, ?)", column, cmp), p.Cursor.Value, p.Cursor.ID)
+	}
+
+	return db.Order(fmt.Sprintf("%s %s, contents.id %s", column, direction, direction)).Limit(p.Limit + 1)
+}
+
+// Next trims the extra row fetched by Apply and returns the cursor of the following page
+func (p Page) Next(contents []models.Content) ([]models.Content, string) {
+	if len(contents) <= p.Limit {
+		return contents, ""
+	}
+	contents = contents[:p.Limit]
+	return contents, p.CursorAt(contents[len(contents)-1]).Encode()
+}
+
+// CursorAt returns the cursor of the page following content
+func (p Page) CursorAt(content models.Content) *Cursor {
+	value := content.CreatedAt
+	if p.Sort == "updatedAt" {
+		value = content.UpdatedAt
+	}
+	return &Cursor{Value: value, ID: content.ID, Sort: p.Sort, Desc: p.Desc}
+}
@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger writes GORM's logs through slog. level is one of silent, error,
+// warn or info; info logs every SQL statement.
+func GormLogger(level string) gormlogger.Interface {
+	return gormlogger.NewSlogLogger(slog.Default().With("component", "gorm"), gormlogger.Config{
+		SlowThreshold:             500 * time.Millisecond,
+		LogLevel:                  parseGormLevel(level),
+		IgnoreRecordNotFoundError: true,
+		ParameterizedQueries:      true,
+	})
+}
+
+func parseGormLevel(s string) gormlogger.LogLevel {
+	switch strings.ToLower(s) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
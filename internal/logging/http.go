@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID returns the ID assigned to the request by Middleware
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware assigns every request an ID, reusing the caller's X-Request-ID
+// when present, echoes it in the response and logs the request once served
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = With(ctx, "request_id", id)
+		r = r.WithContext(ctx)
+
+		rec := response.NewRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		FromContext(ctx).Info("request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.Status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey struct{}
+
+// InitLogger installs the process-wide slog logger. The standard library log
+// package writes through it as well.
+func InitLogger(cfg config.LogConfig) {
+	InitLoggerTo(os.Stdout, cfg)
+}
+
+// InitLoggerTo is InitLogger writing to w, for tools whose stdout is their output
+func InitLoggerTo(w io.Writer, cfg config.LogConfig) {
+	opts := &slog.HandlerOptions{Level: parseLevel(cfg.Level)}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+func parseLevel(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FromContext returns the logger carried by ctx, with the trace ID of the
+// active span attached so log lines can be matched to traces
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(ctxKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+	return logger
+}
+
+// With returns a context whose logger carries the given attributes
+func With(ctx context.Context, args ...any) context.Context {
+	logger, ok := ctx.Value(ctxKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	return context.WithValue(ctx, ctxKey{}, logger.With(args...))
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// taskRefs picks the identifiers task payloads share so they can be logged
+// without knowing the payload type
+type taskRefs struct {
+	ContentID  uuid.UUID
+	DeliveryID uuid.UUID
+	ExportID   uuid.UUID
+}
+
+// TaskMiddleware attaches the task ID, type, queue and any content, delivery
+// or export ID in the payload to the context logger
+func TaskMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		args := []any{"task_type", t.Type()}
+		if id, ok := tasks.TaskID(ctx); ok {
+			args = append(args, "task_id", id)
+		}
+		if queue, ok := tasks.QueueName(ctx); ok {
+			args = append(args, "queue", queue)
+		}
+
+		var refs taskRefs
+		if err := json.Unmarshal(t.Payload(), &refs); err == nil {
+			if refs.ContentID != uuid.Nil {
+				args = append(args, "content_id", refs.ContentID.String())
+			}
+			if refs.DeliveryID != uuid.Nil {
+				args = append(args, "delivery_id", refs.DeliveryID.String())
+			}
+			if refs.ExportID != uuid.Nil {
+				args = append(args, "export_id", refs.ExportID.String())
+			}
+		}
+
+		ctx = With(ctx, args...)
+		err := next.ProcessTask(ctx, t)
+		if err != nil {
+			FromContext(ctx).Error("task failed", "error", err)
+		}
+		return err
+	})
+}
+
+// asynqLogger routes the asynq server's own logs through slog
+type asynqLogger struct {
+	logger *slog.Logger
+}
+
+// AsynqLogger adapts the default slog logger for asynq.Config.Logger
+func AsynqLogger() asynq.Logger {
+	return asynqLogger{logger: slog.Default().With("component", "asynq")}
+}
+
+func (l asynqLogger) Debug(args ...interface{}) { l.logger.Debug(fmt.Sprint(args...)) }
+func (l asynqLogger) Info(args ...interface{})  { l.logger.Info(fmt.Sprint(args...)) }
+func (l asynqLogger) Warn(args ...interface{})  { l.logger.Warn(fmt.Sprint(args...)) }
+func (l asynqLogger) Error(args ...interface{}) { l.logger.Error(fmt.Sprint(args...)) }
+func (l asynqLogger) Fatal(args ...interface{}) {
+	l.logger.Error(fmt.Sprint(args...))
+	os.Exit(1)
+}
@@ -0,0 +1,127 @@
+// Package metering records what moderation costs: the tokens every model
+// call uses and its price, per tenant and content item, with daily totals
+// the usage reports read and an alert when a tenant reaches its monthly
+// budget. Days and months follow the UTC calendar.
+package metering
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+)
+
+var cfg config.MeteringConfig
+
+// Init sets the price table and budgets
+func Init(c config.MeteringConfig) {
+	cfg = c
+}
+
+// Call is a model call to record
+type Call struct {
+	TenantID  string
+	ContentID uuid.UUID
+	MediaType models.MediaType
+	Model     string
+	// Usage is the UsageMetadata of the model's response
+	Usage *genai.GenerateContentResponseUsageMetadata
+}
+
+// Record stores the call and raises a budget alert when it takes the tenant
+// to its budget for the month. The model has answered by now, so a failure
+// here is logged rather than failing the task, which would call it again.
+func Record(ctx context.Context, repos *repository.Repositories, call Call) {
+	logger := logging.FromContext(ctx)
+
+	record := models.ModelCall{
+		TenantID:  call.TenantID,
+		ContentID: call.ContentID,
+		MediaType: call.MediaType,
+		Model:     call.Model,
+	}
+	if u := call.Usage; u != nil {
+		record.InputTokens = int64(u.PromptTokenCount) + int64(u.ToolUsePromptTokenCount)
+		record.OutputTokens = int64(u.CandidatesTokenCount) + int64(u.ThoughtsTokenCount)
+	} else {
+		logger.Warn("model response has no usage metadata", "model", call.Model)
+	}
+	record.Cost = Cost(call.Model, record.InputTokens, record.OutputTokens)
+
+	if err := repos.Usage.Record(ctx, &record); err != nil {
+		logger.Error("failed to record model usage", "model", call.Model, "error", err)
+		return
+	}
+	metrics.ModelTokens.WithLabelValues(call.Model, "input").Add(float64(record.InputTokens))
+	metrics.ModelTokens.WithLabelValues(call.Model, "output").Add(float64(record.OutputTokens))
+	metrics.ModelCost.WithLabelValues(call.Model).Add(record.Cost)
+
+	if err := checkBudget(ctx, repos, call.TenantID, record.CreatedAt); err != nil {
+		logger.Error("failed to check model budget", "tenant_id", call.TenantID, "error", err)
+	}
+}
+
+// Cost estimates what a call costs in US dollars from the price table. Models
+// missing from it cost nothing.
+func Cost(model string, inputTokens, outputTokens int64) float64 {
+	price, ok := cfg.Prices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(inputTokens)*price.InputPerMillion + float64(outputTokens)*price.OutputPerMillion) / 1e6
+}
+
+// Budget returns the tenant's monthly budget in US dollars, 0 when it has none
+func Budget(tenantID string) float64 {
+	if budget, ok := cfg.MonthlyBudgets[tenantID]; ok {
+		return budget
+	}
+	return cfg.MonthlyBudget
+}
+
+// Month returns the bounds of the UTC month t falls in, and its YYYY-MM name
+func Month(t time.Time) (from, to time.Time, name string) {
+	t = t.UTC()
+	from = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0), from.Format("2006-01")
+}
+
+// checkBudget raises the tenant's alert for the month of at, once, when its
+// spend has reached the budget
+func checkBudget(ctx context.Context, repos *repository.Repositories, tenantID string, at time.Time) error {
+	budget := Budget(tenantID)
+	if budget <= 0 {
+		return nil
+	}
+
+	from, to, month := Month(at)
+	cost, err := repos.Usage.Cost(ctx, tenantID, from, to)
+	if err != nil {
+		return err
+	}
+	if cost < budget {
+		return nil
+	}
+
+	created, err := repos.Usage.CreateAlert(ctx, &models.BudgetAlert{
+		TenantID: tenantID,
+		Month:    month,
+		Budget:   budget,
+		Cost:     cost,
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		metrics.BudgetAlerts.Inc()
+		logging.FromContext(ctx).Warn("tenant reached its monthly model budget",
+			"tenant_id", tenantID, "month", month, "budget", budget, "cost", cost)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sreejit-Sengupto/utils/response"
+	"github.com/gorilla/mux"
+)
+
+// Middleware records request counts and latencies per route template. It is
+// meant for mux.Router.Use so the matched route is known.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		rec := response.NewRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		HTTPDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.Status)).Inc()
+	})
+}
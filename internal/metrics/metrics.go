@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "moderation"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	TasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_processed_total",
+		Help:      "Queue tasks processed by task type and result (success or failure).",
+	}, []string{"task_type", "result"})
+
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Queue task processing time by task type.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"task_type"})
+
+	ModelRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_requests_total",
+		Help:      "Moderation model calls by model.",
+	}, []string{"model"})
+
+	ModelErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_errors_total",
+		Help:      "Failed moderation model calls by model.",
+	}, []string{"model"})
+
+	ModelLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "model_request_duration_seconds",
+		Help:      "Moderation model call latency by model.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"model"})
+
+	CircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_state",
+		Help:      "State of the circuit breaker around each model: 0 closed, 1 half-open, 2 open.",
+	}, []string{"model"})
+
+	CircuitTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_transitions_total",
+		Help:      "Circuit breaker state changes by model and the state entered.",
+	}, []string{"model", "state"})
+
+	ModelFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_fallbacks_total",
+		Help:      "Modalities moderated by a fallback step (a model name, rules or hold) instead of their own model, by media type.",
+	}, []string{"media_type", "step"})
+
+	Verdicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verdicts_total",
+		Help:      "Moderation verdicts by media type and status. Final statuses use media_type FINAL.",
+	}, []string{"media_type", "status"})
+
+	ModelTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_tokens_total",
+		Help:      "Tokens used by moderation model calls by model and direction (input or output).",
+	}, []string{"model", "direction"})
+
+	ModelCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_cost_dollars_total",
+		Help:      "Estimated cost of moderation model calls in US dollars by model.",
+	}, []string{"model"})
+
+	BudgetAlerts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "budget_alerts_total",
+		Help:      "Tenants whose model spend reached their monthly budget.",
+	})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests refused by the rate limiter or a quota, by limit (rate, day or month).",
+	}, []string{"limit"})
+)
+
+// ObserveModelCall records the outcome and latency of one model call
+func ObserveModelCall(model string, duration time.Duration, err error) {
+	ModelRequests.WithLabelValues(model).Inc()
+	ModelLatency.WithLabelValues(model).Observe(duration.Seconds())
+	if err != nil {
+		ModelErrors.WithLabelValues(model).Inc()
+	}
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TaskMiddleware records processing counts and durations per task type
+func TaskMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+
+		TaskDuration.WithLabelValues(t.Type()).Observe(time.Since(start).Seconds())
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		TasksProcessed.WithLabelValues(t.Type(), result).Inc()
+		return err
+	})
+}
+
+// QueueInspector reports a queue's task counts; *asynq.Inspector reads them
+// from Redis and the in-process queue from its own memory
+type QueueInspector interface {
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// queueCollector reads queue sizes at scrape time, so with Redis the gauges
+// are right no matter which process enqueued or processed the tasks
+type queueCollector struct {
+	inspector QueueInspector
+	queues    []string
+	depth     *prometheus.Desc
+	latency   *prometheus.Desc
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.latency
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, queue := range c.queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if err != nil {
+			// A queue that never had a task doesn't exist in Redis yet
+			continue
+		}
+		states := map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+		}
+		for state, n := range states {
+			ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(n), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+	}
+}
+
+var inspector *asynq.Inspector
+
+// InitQueueMetrics registers the queue depth gauges for the given queues
+func InitQueueMetrics(cfg config.RedisConfig, queues []string) {
+
+	opt, err := asynq.ParseRedisURI(cfg.URL)
+	if err != nil {
+		log.Fatalf("Failed to parse REDIS_URL: %v", err)
+		return
+	}
+
+	inspector = asynq.NewInspector(opt)
+	RegisterQueueMetrics(inspector, queues)
+}
+
+// RegisterQueueMetrics registers the queue depth gauges read from the given inspector
+func RegisterQueueMetrics(inspector QueueInspector, queues []string) {
+	prometheus.MustRegister(&queueCollector{
+		inspector: inspector,
+		queues:    queues,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "tasks"),
+			"Tasks in each queue by state.",
+			[]string{"queue", "state"}, nil,
+		),
+		latency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "latency_seconds"),
+			"Age of the oldest pending task in each queue.",
+			[]string{"queue"}, nil,
+		),
+	})
+}
+
+func CloseQueueMetrics() {
+	if inspector != nil {
+		inspector.Close()
+	}
+}
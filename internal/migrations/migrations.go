@@ -0,0 +1,342 @@
+// Package migrations applies the versioned SQL schema migrations embedded
+// from sql/<dialect>/. Files are named NNNN_name.up.sql and
+// NNNN_name.down.sql, and applied versions are recorded in the
+// schema_migrations table. Both dialects share version numbers, so a version
+// means the same schema whichever database it was applied to.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/postgres/*.sql sql/sqlite/*.sql
+var files embed.FS
+
+// Dialect selects the set of migrations; its values match the names of the
+// GORM dialectors
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// lockKey identifies the Postgres advisory lock held while migrating, so only
+// one instance changes the schema at a time
+const lockKey = 7_420_001
+
+type Migration struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	up        string
+	down      string
+}
+
+// All returns the embedded migrations of the dialect in version order
+func All(d Dialect) ([]Migration, error) {
+	if d != Postgres && d != SQLite {
+		return nil, fmt.Errorf("no migrations for database dialect %q", d)
+	}
+	dir := "sql/" + string(d)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".sql")
+		if !ok {
+			continue
+		}
+		name, direction, ok := cutLast(name, ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+		}
+		rawVersion, label, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+		}
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", e.Name(), rawVersion)
+		}
+
+		body, err := files.ReadFile(dir + "/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if m.Name != label {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	all := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all, nil
+}
+
+// Latest returns the highest embedded version
+func Latest(d Dialect) (int, error) {
+	all, err := All(d)
+	if err != nil || len(all) == 0 {
+		return 0, err
+	}
+	return all[len(all)-1].Version, nil
+}
+
+// Status returns every embedded migration with the time it was applied, if it was
+func Status(ctx context.Context, db *sql.DB, d Dialect) ([]Migration, error) {
+	all, err := All(d)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := appliedVersions(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if at, ok := applied[all[i].Version]; ok {
+			all[i].AppliedAt = &at
+		}
+	}
+	return all, nil
+}
+
+// Up applies every pending migration
+func Up(ctx context.Context, db *sql.DB, d Dialect) error {
+	latest, err := Latest(d)
+	if err != nil {
+		return err
+	}
+	return To(ctx, db, d, latest)
+}
+
+// Down reverts the given number of applied migrations, newest first
+func Down(ctx context.Context, db *sql.DB, d Dialect, steps int) error {
+	if steps <= 0 {
+		return errors.New("steps must be positive")
+	}
+	status, err := Status(ctx, db, d)
+	if err != nil {
+		return err
+	}
+
+	var applied []int
+	for _, m := range status {
+		if m.AppliedAt != nil {
+			applied = append(applied, m.Version)
+		}
+	}
+	if steps > len(applied) {
+		return fmt.Errorf("only %d migrations are applied", len(applied))
+	}
+
+	target := 0
+	if steps < len(applied) {
+		target = applied[len(applied)-steps-1]
+	}
+	return To(ctx, db, d, target)
+}
+
+// To migrates up or down until exactly the migrations up to version are
+// applied. Version 0 reverts everything.
+func To(ctx context.Context, db *sql.DB, d Dialect, version int) error {
+	all, err := All(d)
+	if err != nil {
+		return err
+	}
+	if version != 0 && !containsVersion(all, version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	// Advisory locks belong to a session, so everything runs on one connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// SQLite has no advisory locks, but it has a single writer: each
+	// migration's transaction takes the write lock, so an instance racing
+	// another fails and rolls back rather than applying a version twice
+	if d == Postgres {
+		if err := lock(ctx, conn); err != nil {
+			return err
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+				slog.Error("failed to release migration lock", "error", err)
+			}
+		}()
+	}
+
+	// Read what is applied only once the lock is held, since another
+	// instance may have just migrated
+	applied, err := appliedVersions(ctx, conn, d)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		if _, ok := applied[m.Version]; !ok && m.Version <= version {
+			if err := apply(ctx, conn, m, true); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; ok && m.Version > version {
+			if err := apply(ctx, conn, m, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Applied versions the binary doesn't know about come from a newer release
+	for v := range applied {
+		if !containsVersion(all, v) {
+			slog.Warn("database has a migration this binary does not know", "version", v)
+		}
+	}
+	return nil
+}
+
+// lock takes the migration advisory lock, waiting for another instance to finish
+func lock(ctx context.Context, conn *sql.Conn) error {
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	if locked {
+		return nil
+	}
+
+	slog.Info("waiting for another instance to finish migrating")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	return nil
+}
+
+// apply runs one migration in its own transaction together with its schema_migrations row
+func apply(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	direction, body := "up", m.up
+	if !up {
+		direction, body = "down", m.down
+	}
+
+	start := time.Now()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", m.Version, m.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	slog.Info("applied migration", "version", m.Version, "name", m.Name, "direction", direction, "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+var schemaMigrationsDDL = map[Dialect]string{
+	Postgres: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`,
+	SQLite: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name text NOT NULL,
+		applied_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn, d Dialect) (map[int]time.Time, error) {
+	if _, err := conn.ExecContext(ctx, schemaMigrationsDDL[d]); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func containsVersion(all []Migration, version int) bool {
+	for _, m := range all {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
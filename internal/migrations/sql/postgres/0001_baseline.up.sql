@@ -0,0 +1,168 @@
+-- Baseline schema, matching what AutoMigrate created before versioned
+-- migrations. Every statement is IF NOT EXISTS so databases created by
+-- AutoMigrate adopt it without changes.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS contents (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text,
+    author_id text,
+    batch_id uuid,
+    text text,
+    image text,
+    video text,
+    search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(text, ''))) STORED,
+    text_status text,
+    image_status text,
+    video_status text,
+    final_status text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_contents_created_id ON contents (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_contents_updated_id ON contents (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_contents_tenant_created ON contents (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_contents_author_created ON contents (author_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_contents_final_status_created ON contents (final_status, created_at);
+CREATE INDEX IF NOT EXISTS idx_contents_batch_id ON contents (batch_id);
+CREATE INDEX IF NOT EXISTS idx_contents_text_status ON contents (text_status);
+CREATE INDEX IF NOT EXISTS idx_contents_image_status ON contents (image_status);
+CREATE INDEX IF NOT EXISTS idx_contents_video_status ON contents (video_status);
+CREATE INDEX IF NOT EXISTS idx_contents_search ON contents USING gin (search_vector);
+
+CREATE TABLE IF NOT EXISTS moderation_results (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    content_id uuid NOT NULL,
+    media_type text NOT NULL,
+    status text NOT NULL,
+    risk_score decimal NOT NULL,
+    category text,
+    model text,
+    policy_version text,
+    explaination text,
+    explanation_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(explaination, ''))) STORED,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_results_content_risk ON moderation_results (content_id, risk_score);
+CREATE INDEX IF NOT EXISTS idx_moderation_results_category ON moderation_results (category);
+CREATE INDEX IF NOT EXISTS idx_moderation_results_model ON moderation_results (model);
+CREATE INDEX IF NOT EXISTS idx_moderation_results_policy_version ON moderation_results (policy_version);
+CREATE INDEX IF NOT EXISTS idx_results_explanation_search ON moderation_results USING gin (explanation_vector);
+
+CREATE TABLE IF NOT EXISTS moderation_events (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    content_id uuid NOT NULL,
+    event_type text NOT NULL,
+    media_type text,
+    queue text,
+    status text,
+    payload jsonb,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_events_content_created ON moderation_events (content_id, created_at);
+
+CREATE TABLE IF NOT EXISTS audits (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    content_id uuid NOT NULL,
+    action text NOT NULL,
+    reason text,
+    previous_final_status text,
+    text_status text,
+    image_status text,
+    video_status text,
+    final_status text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audits_content_id ON audits (content_id);
+CREATE INDEX IF NOT EXISTS idx_audits_created_at ON audits (created_at);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text NOT NULL,
+    url text NOT NULL,
+    description text,
+    secret text NOT NULL,
+    events jsonb,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant_id ON webhook_subscriptions (tenant_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id uuid NOT NULL,
+    content_id uuid,
+    event_type text NOT NULL,
+    payload jsonb,
+    status text NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    response_code bigint,
+    last_error text,
+    delivered_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_content_id ON webhook_deliveries (content_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    delivery_id uuid NOT NULL,
+    response_code bigint,
+    response_body text,
+    error text,
+    duration_ms bigint,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+CREATE TABLE IF NOT EXISTS batches (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text NOT NULL,
+    total bigint NOT NULL,
+    accepted bigint NOT NULL,
+    rejected bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_batches_tenant_id ON batches (tenant_id);
+
+CREATE TABLE IF NOT EXISTS exports (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text NOT NULL,
+    format text NOT NULL,
+    filters jsonb,
+    status text NOT NULL,
+    row_count bigint,
+    size_bytes bigint,
+    location text,
+    error text,
+    created_at timestamptz,
+    completed_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_exports_tenant_id ON exports (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_exports_status ON exports (status);
+
+CREATE TABLE IF NOT EXISTS blocklist_entries (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text NOT NULL,
+    term text NOT NULL,
+    category text NOT NULL,
+    reason text,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_blocklist_tenant_term ON blocklist_entries (tenant_id, term);
+
+CREATE TABLE IF NOT EXISTS policies (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id text NOT NULL,
+    media_type text NOT NULL,
+    version text NOT NULL,
+    instruction text NOT NULL,
+    active boolean NOT NULL DEFAULT false,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_policies_tenant_media_version ON policies (tenant_id, media_type, version);
+CREATE INDEX IF NOT EXISTS idx_policies_active ON policies (active);
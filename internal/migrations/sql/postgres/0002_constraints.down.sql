@@ -0,0 +1,40 @@
+DROP INDEX IF EXISTS idx_policies_one_active;
+
+ALTER TABLE policies DROP CONSTRAINT IF EXISTS chk_policies_media_type;
+
+ALTER TABLE blocklist_entries DROP CONSTRAINT IF EXISTS chk_blocklist_entries_category;
+
+ALTER TABLE exports
+    DROP CONSTRAINT IF EXISTS chk_exports_format,
+    DROP CONSTRAINT IF EXISTS chk_exports_status;
+
+ALTER TABLE webhook_attempts DROP CONSTRAINT IF EXISTS fk_webhook_attempts_delivery;
+
+ALTER TABLE webhook_deliveries
+    DROP CONSTRAINT IF EXISTS fk_webhook_deliveries_subscription,
+    DROP CONSTRAINT IF EXISTS chk_webhook_deliveries_event_type,
+    DROP CONSTRAINT IF EXISTS chk_webhook_deliveries_status;
+
+ALTER TABLE audits
+    DROP CONSTRAINT IF EXISTS fk_audits_content,
+    DROP CONSTRAINT IF EXISTS chk_audits_action,
+    DROP CONSTRAINT IF EXISTS chk_audits_final_status;
+
+ALTER TABLE moderation_events
+    DROP CONSTRAINT IF EXISTS fk_moderation_events_content,
+    DROP CONSTRAINT IF EXISTS chk_moderation_events_event_type,
+    DROP CONSTRAINT IF EXISTS chk_moderation_events_media_type,
+    DROP CONSTRAINT IF EXISTS chk_moderation_events_status;
+
+ALTER TABLE moderation_results
+    DROP CONSTRAINT IF EXISTS fk_moderation_results_content,
+    DROP CONSTRAINT IF EXISTS chk_moderation_results_media_type,
+    DROP CONSTRAINT IF EXISTS chk_moderation_results_status,
+    DROP CONSTRAINT IF EXISTS chk_moderation_results_category;
+
+ALTER TABLE contents
+    DROP CONSTRAINT IF EXISTS fk_contents_batch,
+    DROP CONSTRAINT IF EXISTS chk_contents_text_status,
+    DROP CONSTRAINT IF EXISTS chk_contents_image_status,
+    DROP CONSTRAINT IF EXISTS chk_contents_video_status,
+    DROP CONSTRAINT IF EXISTS chk_contents_final_status;
@@ -0,0 +1,63 @@
+-- Explicit foreign keys and value checks. AutoMigrate created a foreign key
+-- for each side of a relation under its own names; those are replaced.
+
+ALTER TABLE moderation_results DROP CONSTRAINT IF EXISTS fk_contents_moderation_result;
+ALTER TABLE moderation_results DROP CONSTRAINT IF EXISTS fk_moderation_results_content;
+ALTER TABLE moderation_events DROP CONSTRAINT IF EXISTS fk_contents_moderation_events;
+ALTER TABLE moderation_events DROP CONSTRAINT IF EXISTS fk_moderation_events_content;
+ALTER TABLE audits DROP CONSTRAINT IF EXISTS fk_contents_audit;
+ALTER TABLE audits DROP CONSTRAINT IF EXISTS fk_audits_content;
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS fk_webhook_deliveries_subscription;
+ALTER TABLE webhook_attempts DROP CONSTRAINT IF EXISTS fk_webhook_deliveries_webhook_attempt;
+
+-- AutoMigrate stored this as text since it had no relation to take the type from
+ALTER TABLE webhook_deliveries ALTER COLUMN content_id TYPE uuid USING content_id::uuid;
+
+ALTER TABLE contents
+    ADD CONSTRAINT fk_contents_batch FOREIGN KEY (batch_id) REFERENCES batches (id) ON DELETE SET NULL,
+    -- An empty modality status means it has not been moderated
+    ADD CONSTRAINT chk_contents_text_status CHECK (text_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    ADD CONSTRAINT chk_contents_image_status CHECK (image_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    ADD CONSTRAINT chk_contents_video_status CHECK (video_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    ADD CONSTRAINT chk_contents_final_status CHECK (final_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED'));
+
+ALTER TABLE moderation_results
+    ADD CONSTRAINT fk_moderation_results_content FOREIGN KEY (content_id) REFERENCES contents (id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_moderation_results_media_type CHECK (media_type IN ('TXT', 'IMG', 'VID')),
+    ADD CONSTRAINT chk_moderation_results_status CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    ADD CONSTRAINT chk_moderation_results_category CHECK (category IN ('', 'NONE', 'HATE', 'HARASSMENT', 'SEXUAL', 'VIOLENCE', 'SELF_HARM', 'ILLEGAL', 'MISINFORMATION', 'SPAM', 'OTHER'));
+
+ALTER TABLE moderation_events
+    ADD CONSTRAINT fk_moderation_events_content FOREIGN KEY (content_id) REFERENCES contents (id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_moderation_events_event_type CHECK (event_type IN ('CREATED', 'UPDATED', 'MODERATED', 'AGGREGATED', 'REVIEWED', 'REMODERATED')),
+    ADD CONSTRAINT chk_moderation_events_media_type CHECK (media_type IN ('', 'TXT', 'IMG', 'VID')),
+    ADD CONSTRAINT chk_moderation_events_status CHECK (status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED'));
+
+-- Overrides used to be audited under a misspelled action
+UPDATE audits SET action = 'OVERRIDEN' WHERE action = 'OVERIDDEN';
+
+ALTER TABLE audits
+    ADD CONSTRAINT fk_audits_content FOREIGN KEY (content_id) REFERENCES contents (id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_audits_action CHECK (action IN ('REVIEWED', 'OVERRIDEN')),
+    ADD CONSTRAINT chk_audits_final_status CHECK (final_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED'));
+
+ALTER TABLE webhook_deliveries
+    ADD CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_webhook_deliveries_event_type CHECK (event_type IN ('content.final_status', 'content.overridden')),
+    ADD CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED'));
+
+ALTER TABLE webhook_attempts
+    ADD CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id) ON DELETE CASCADE;
+
+ALTER TABLE exports
+    ADD CONSTRAINT chk_exports_format CHECK (format IN ('CSV', 'NDJSON')),
+    ADD CONSTRAINT chk_exports_status CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED'));
+
+ALTER TABLE blocklist_entries
+    ADD CONSTRAINT chk_blocklist_entries_category CHECK (category IN ('NONE', 'HATE', 'HARASSMENT', 'SEXUAL', 'VIOLENCE', 'SELF_HARM', 'ILLEGAL', 'MISINFORMATION', 'SPAM', 'OTHER'));
+
+ALTER TABLE policies
+    ADD CONSTRAINT chk_policies_media_type CHECK (media_type IN ('TXT', 'IMG'));
+
+-- ActivatePolicy keeps one active policy per tenant and media type; this enforces it
+CREATE UNIQUE INDEX idx_policies_one_active ON policies (tenant_id, media_type) WHERE active;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS moderation_results_fts;
+DROP TABLE IF EXISTS contents_fts;
+DROP TABLE IF EXISTS policies;
+DROP TABLE IF EXISTS blocklist_entries;
+DROP TABLE IF EXISTS exports;
+DROP TABLE IF EXISTS webhook_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS audits;
+DROP TABLE IF EXISTS moderation_events;
+DROP TABLE IF EXISTS moderation_results;
+DROP TABLE IF EXISTS contents;
+DROP TABLE IF EXISTS batches;
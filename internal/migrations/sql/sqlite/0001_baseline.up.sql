@@ -0,0 +1,193 @@
+-- The Postgres schema as of 0002_constraints, for SQLite. SQLite can't add
+-- constraints to an existing table, so the foreign keys and checks are part
+-- of the baseline here. IDs are generated by the application, and timestamps
+-- are DATETIME so the driver reads them back as times.
+
+CREATE TABLE batches (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    total integer NOT NULL,
+    accepted integer NOT NULL,
+    rejected integer NOT NULL,
+    created_at datetime
+);
+CREATE INDEX idx_batches_tenant_id ON batches (tenant_id);
+
+CREATE TABLE contents (
+    id text PRIMARY KEY,
+    tenant_id text,
+    author_id text,
+    batch_id text REFERENCES batches (id) ON DELETE SET NULL,
+    text text,
+    image text,
+    video text,
+    -- An empty modality status means it has not been moderated
+    text_status text CHECK (text_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    image_status text CHECK (image_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    video_status text CHECK (video_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    final_status text CHECK (final_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    created_at datetime,
+    updated_at datetime
+);
+CREATE INDEX idx_contents_created_id ON contents (created_at, id);
+CREATE INDEX idx_contents_updated_id ON contents (updated_at, id);
+CREATE INDEX idx_contents_tenant_created ON contents (tenant_id, created_at);
+CREATE INDEX idx_contents_author_created ON contents (author_id, created_at);
+CREATE INDEX idx_contents_final_status_created ON contents (final_status, created_at);
+CREATE INDEX idx_contents_batch_id ON contents (batch_id);
+CREATE INDEX idx_contents_text_status ON contents (text_status);
+CREATE INDEX idx_contents_image_status ON contents (image_status);
+CREATE INDEX idx_contents_video_status ON contents (video_status);
+
+CREATE TABLE moderation_results (
+    id text PRIMARY KEY,
+    content_id text NOT NULL REFERENCES contents (id) ON DELETE CASCADE,
+    media_type text NOT NULL CHECK (media_type IN ('TXT', 'IMG', 'VID')),
+    status text NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    risk_score real NOT NULL,
+    category text CHECK (category IN ('', 'NONE', 'HATE', 'HARASSMENT', 'SEXUAL', 'VIOLENCE', 'SELF_HARM', 'ILLEGAL', 'MISINFORMATION', 'SPAM', 'OTHER')),
+    model text,
+    policy_version text,
+    explaination text,
+    created_at datetime
+);
+CREATE INDEX idx_results_content_risk ON moderation_results (content_id, risk_score);
+CREATE INDEX idx_moderation_results_category ON moderation_results (category);
+CREATE INDEX idx_moderation_results_model ON moderation_results (model);
+CREATE INDEX idx_moderation_results_policy_version ON moderation_results (policy_version);
+
+CREATE TABLE moderation_events (
+    id text PRIMARY KEY,
+    content_id text NOT NULL REFERENCES contents (id) ON DELETE CASCADE,
+    event_type text NOT NULL CHECK (event_type IN ('CREATED', 'UPDATED', 'MODERATED', 'AGGREGATED', 'REVIEWED', 'REMODERATED')),
+    media_type text CHECK (media_type IN ('', 'TXT', 'IMG', 'VID')),
+    queue text,
+    status text CHECK (status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    payload text,
+    created_at datetime
+);
+CREATE INDEX idx_events_content_created ON moderation_events (content_id, created_at);
+
+CREATE TABLE audits (
+    id text PRIMARY KEY,
+    content_id text NOT NULL REFERENCES contents (id) ON DELETE CASCADE,
+    action text NOT NULL CHECK (action IN ('REVIEWED', 'OVERRIDEN')),
+    reason text,
+    previous_final_status text,
+    text_status text,
+    image_status text,
+    video_status text,
+    final_status text CHECK (final_status IN ('', 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED')),
+    created_at datetime
+);
+CREATE INDEX idx_audits_content_id ON audits (content_id);
+CREATE INDEX idx_audits_created_at ON audits (created_at);
+
+CREATE TABLE webhook_subscriptions (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    url text NOT NULL,
+    description text,
+    secret text NOT NULL,
+    events text,
+    active boolean NOT NULL DEFAULT true,
+    created_at datetime,
+    updated_at datetime
+);
+CREATE INDEX idx_webhook_subscriptions_tenant_id ON webhook_subscriptions (tenant_id);
+
+CREATE TABLE webhook_deliveries (
+    id text PRIMARY KEY,
+    subscription_id text NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    content_id text,
+    event_type text NOT NULL CHECK (event_type IN ('content.final_status', 'content.overridden')),
+    payload text,
+    status text NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED')),
+    attempts integer NOT NULL DEFAULT 0,
+    response_code integer,
+    last_error text,
+    delivered_at datetime,
+    created_at datetime,
+    updated_at datetime
+);
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX idx_webhook_deliveries_content_id ON webhook_deliveries (content_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries (status);
+
+CREATE TABLE webhook_attempts (
+    id text PRIMARY KEY,
+    delivery_id text NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    response_code integer,
+    response_body text,
+    error text,
+    duration_ms integer,
+    created_at datetime
+);
+CREATE INDEX idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+CREATE TABLE exports (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    format text NOT NULL CHECK (format IN ('CSV', 'NDJSON')),
+    filters text,
+    status text NOT NULL CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED')),
+    row_count integer,
+    size_bytes integer,
+    location text,
+    error text,
+    created_at datetime,
+    completed_at datetime
+);
+CREATE INDEX idx_exports_tenant_id ON exports (tenant_id);
+CREATE INDEX idx_exports_status ON exports (status);
+
+CREATE TABLE blocklist_entries (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    term text NOT NULL,
+    category text NOT NULL CHECK (category IN ('NONE', 'HATE', 'HARASSMENT', 'SEXUAL', 'VIOLENCE', 'SELF_HARM', 'ILLEGAL', 'MISINFORMATION', 'SPAM', 'OTHER')),
+    reason text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX idx_blocklist_tenant_term ON blocklist_entries (tenant_id, term);
+
+CREATE TABLE policies (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    media_type text NOT NULL CHECK (media_type IN ('TXT', 'IMG')),
+    version text NOT NULL,
+    instruction text NOT NULL,
+    active boolean NOT NULL DEFAULT false,
+    created_at datetime
+);
+CREATE UNIQUE INDEX idx_policies_tenant_media_version ON policies (tenant_id, media_type, version);
+CREATE INDEX idx_policies_active ON policies (active);
+-- ActivatePolicy keeps one active policy per tenant and media type; this enforces it
+CREATE UNIQUE INDEX idx_policies_one_active ON policies (tenant_id, media_type) WHERE active;
+
+-- Full-text search stands in for the Postgres tsvector columns. The FTS5
+-- tables keep their own copy of the text keyed by ID, since the rowids of
+-- tables without an integer primary key can change on VACUUM.
+CREATE VIRTUAL TABLE contents_fts USING fts5(id UNINDEXED, text, tokenize = 'porter unicode61');
+
+CREATE TRIGGER contents_fts_insert AFTER INSERT ON contents BEGIN
+    INSERT INTO contents_fts (id, text) VALUES (new.id, coalesce(new.text, ''));
+END;
+CREATE TRIGGER contents_fts_update AFTER UPDATE OF text ON contents WHEN old.text IS NOT new.text BEGIN
+    UPDATE contents_fts SET text = coalesce(new.text, '') WHERE id = old.id;
+END;
+CREATE TRIGGER contents_fts_delete AFTER DELETE ON contents BEGIN
+    DELETE FROM contents_fts WHERE id = old.id;
+END;
+
+CREATE VIRTUAL TABLE moderation_results_fts USING fts5(id UNINDEXED, content_id UNINDEXED, explaination, tokenize = 'porter unicode61');
+
+CREATE TRIGGER moderation_results_fts_insert AFTER INSERT ON moderation_results BEGIN
+    INSERT INTO moderation_results_fts (id, content_id, explaination) VALUES (new.id, new.content_id, coalesce(new.explaination, ''));
+END;
+CREATE TRIGGER moderation_results_fts_update AFTER UPDATE OF explaination ON moderation_results WHEN old.explaination IS NOT new.explaination BEGIN
+    UPDATE moderation_results_fts SET explaination = coalesce(new.explaination, '') WHERE id = old.id;
+END;
+CREATE TRIGGER moderation_results_fts_delete AFTER DELETE ON moderation_results BEGIN
+    DELETE FROM moderation_results_fts WHERE id = old.id;
+END;
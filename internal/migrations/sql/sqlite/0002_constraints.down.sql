@@ -0,0 +1,2 @@
+-- Nothing to revert; see 0002_constraints.up.sql
+SELECT 1;
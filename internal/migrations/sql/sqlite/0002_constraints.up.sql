@@ -0,0 +1,3 @@
+-- The constraints Postgres adds here are part of the SQLite baseline. This
+-- migration only keeps the version numbers of both dialects in step.
+SELECT 1;
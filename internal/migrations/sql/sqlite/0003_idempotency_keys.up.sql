@@ -0,0 +1,15 @@
+-- Responses to requests made with an Idempotency-Key header, replayed when
+-- the request is repeated. status_code is 0 while the first request runs.
+
+CREATE TABLE idempotency_keys (
+    tenant_id text NOT NULL,
+    key text NOT NULL,
+    request_hash text NOT NULL,
+    status_code integer NOT NULL,
+    content_type text,
+    response_body text,
+    created_at datetime,
+    expires_at datetime NOT NULL,
+    PRIMARY KEY (tenant_id, key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budget_alerts;
+DROP TABLE IF EXISTS model_usage_days;
+DROP TABLE IF EXISTS model_calls;
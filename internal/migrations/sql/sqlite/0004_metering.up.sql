@@ -0,0 +1,38 @@
+-- Model usage metering: every model call with its tokens and estimated cost,
+-- daily totals per tenant and model kept up to date as calls are recorded,
+-- and the monthly budget alerts raised from those totals. Calls keep no
+-- foreign key to their content, so spend stays on record if it is deleted.
+
+CREATE TABLE model_calls (
+    id text PRIMARY KEY,
+    tenant_id text NOT NULL,
+    content_id text NOT NULL,
+    media_type text NOT NULL CHECK (media_type IN ('TXT', 'IMG', 'VID')),
+    model text NOT NULL,
+    input_tokens integer NOT NULL,
+    output_tokens integer NOT NULL,
+    cost real NOT NULL,
+    created_at datetime
+);
+CREATE INDEX idx_model_calls_tenant_created ON model_calls (tenant_id, created_at);
+CREATE INDEX idx_model_calls_content_id ON model_calls (content_id);
+
+CREATE TABLE model_usage_days (
+    tenant_id text NOT NULL,
+    day date NOT NULL,
+    model text NOT NULL,
+    calls integer NOT NULL,
+    input_tokens integer NOT NULL,
+    output_tokens integer NOT NULL,
+    cost real NOT NULL,
+    PRIMARY KEY (tenant_id, day, model)
+);
+
+CREATE TABLE budget_alerts (
+    tenant_id text NOT NULL,
+    month text NOT NULL,
+    budget real NOT NULL,
+    cost real NOT NULL,
+    created_at datetime,
+    PRIMARY KEY (tenant_id, month)
+);
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IDs are generated here rather than by a database default, so every
+// database the service runs on stores the same values
+
+func newID(id *uuid.UUID) error {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+	return nil
+}
+
+func (c *Content) BeforeCreate(*gorm.DB) error             { return newID(&c.ID) }
+func (r *ModerationResult) BeforeCreate(*gorm.DB) error    { return newID(&r.ID) }
+func (e *ModerationEvents) BeforeCreate(*gorm.DB) error    { return newID(&e.ID) }
+func (a *Audit) BeforeCreate(*gorm.DB) error               { return newID(&a.ID) }
+func (s *WebhookSubscription) BeforeCreate(*gorm.DB) error { return newID(&s.ID) }
+func (d *WebhookDelivery) BeforeCreate(*gorm.DB) error     { return newID(&d.ID) }
+func (a *WebhookAttempt) BeforeCreate(*gorm.DB) error      { return newID(&a.ID) }
+func (b *Batch) BeforeCreate(*gorm.DB) error               { return newID(&b.ID) }
+func (e *Export) BeforeCreate(*gorm.DB) error              { return newID(&e.ID) }
+func (e *BlocklistEntry) BeforeCreate(*gorm.DB) error      { return newID(&e.ID) }
+func (p *Policy) BeforeCreate(*gorm.DB) error              { return newID(&p.ID) }
+func (c *ModelCall) BeforeCreate(*gorm.DB) error           { return newID(&c.ID) }
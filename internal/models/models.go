@@ -10,15 +10,30 @@ import (
 // 'PENDING', 'APPROVED', 'REJECTED', 'FLAGGED'
 type ContentStatus string
 
-// 'TXT', 'IMG', 'VIDEO'
+// 'TXT', 'IMG', 'VID'
 type MediaType string
 
-// 'CREATED', 'UPDATED', 'MODERATED'
+// 'NONE', 'HATE', 'HARASSMENT', 'SEXUAL', 'VIOLENCE', 'SELF_HARM', 'ILLEGAL', 'MISINFORMATION', 'SPAM', 'OTHER'
+type Category string
+
+// 'CREATED', 'UPDATED', 'MODERATED', 'AGGREGATED', 'REVIEWED', 'REMODERATED'
 type EventType string
 
 // 'REVIEWED', 'OVERRIDEN'
 type Action string
 
+// 'content.final_status', 'content.overridden'
+type WebhookEvent string
+
+// 'PENDING', 'SUCCEEDED', 'FAILED'
+type DeliveryStatus string
+
+// 'CSV', 'NDJSON'
+type ExportFormat string
+
+// 'PENDING', 'RUNNING', 'COMPLETED', 'FAILED'
+type ExportStatus string
+
 const (
 	Pending  ContentStatus = "PENDING"
 	Approved ContentStatus = "APPROVED"
@@ -32,10 +47,32 @@ const (
 	Vid MediaType = "VID"
 )
 
+const (
+	NoCategory     Category = "NONE"
+	Hate           Category = "HATE"
+	Harassment     Category = "HARASSMENT"
+	Sexual         Category = "SEXUAL"
+	Violence       Category = "VIOLENCE"
+	SelfHarm       Category = "SELF_HARM"
+	Illegal        Category = "ILLEGAL"
+	Misinformation Category = "MISINFORMATION"
+	Spam           Category = "SPAM"
+	OtherCategory  Category = "OTHER"
+)
+
+// Categories lists every category, in the order offered to the model
+var Categories = []Category{NoCategory, Hate, Harassment, Sexual, Violence, SelfHarm, Illegal, Misinformation, Spam, OtherCategory}
+
 const (
 	Created   EventType = "CREATED"
 	Updated   EventType = "UPDATED"
 	Moderated EventType = "MODERATED"
+	// Aggregated marks aggregation settling a final status
+	Aggregated EventType = "AGGREGATED"
+	// HumanReviewed marks a reviewer deciding on the content
+	HumanReviewed EventType = "REVIEWED"
+	// Remoderated marks an operator sending the content through moderation again
+	Remoderated EventType = "REMODERATED"
 )
 
 const (
@@ -43,47 +80,224 @@ const (
 	Overriden Action = "OVERRIDEN"
 )
 
+const (
+	ContentFinalStatus WebhookEvent = "content.final_status"
+	ContentOverridden  WebhookEvent = "content.overridden"
+)
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+const (
+	CSV    ExportFormat = "CSV"
+	NDJSON ExportFormat = "NDJSON"
+)
+
+const (
+	ExportPending   ExportStatus = "PENDING"
+	ExportRunning   ExportStatus = "RUNNING"
+	ExportCompleted ExportStatus = "COMPLETED"
+	ExportFailed    ExportStatus = "FAILED"
+)
+
+// The schema is defined by the SQL migrations in internal/migrations, so a
+// field or tag change here needs a new migration
 type Content struct {
-	ID               uuid.UUID          `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ID               uuid.UUID          `gorm:"type:uuid;primaryKey;index:idx_contents_created_id,priority:2;index:idx_contents_updated_id,priority:2" json:"id"`
+	TenantID         string             `gorm:"index:idx_contents_tenant_created,priority:1" json:"tenantId"`
+	AuthorID         string             `gorm:"index:idx_contents_author_created,priority:1" json:"authorId"`
+	BatchID          *uuid.UUID         `gorm:"type:uuid;index" json:"batchId,omitempty"`
 	Text             string             `json:"text"`
 	Image            string             `json:"image"`
 	Video            string             `json:"video"`
-	TextStatus       ContentStatus      `json:"textStatus"`
-	ImageStatus      ContentStatus      `json:"imageStatus"`
-	VideoStatus      ContentStatus      `json:"videoStatus"`
-	FinalStatus      ContentStatus      `json:"finalStatus"`
-	CreatedAt        time.Time          `json:"createdAt"`
-	UpdatedAt        time.Time          `json:"updatedAt"`
+	SearchVector     string             `gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(text, ''))) STORED;index:idx_contents_search,type:gin" json:"-"`
+	TextStatus       ContentStatus      `gorm:"index" json:"textStatus"`
+	ImageStatus      ContentStatus      `gorm:"index" json:"imageStatus"`
+	VideoStatus      ContentStatus      `gorm:"index" json:"videoStatus"`
+	FinalStatus      ContentStatus      `gorm:"index:idx_contents_final_status_created,priority:1" json:"finalStatus"`
+	CreatedAt        time.Time          `gorm:"index:idx_contents_created_id,priority:1;index:idx_contents_tenant_created,priority:2;index:idx_contents_author_created,priority:2;index:idx_contents_final_status_created,priority:2" json:"createdAt"`
+	UpdatedAt        time.Time          `gorm:"index:idx_contents_updated_id,priority:1" json:"updatedAt"`
 	ModerationResult []ModerationResult `json:"moderationResult,omitempty"`
 	ModerationEvents []ModerationEvents `json:"moderationEvents,omitempty"`
 	Audit            []Audit            `json:"audits"`
 }
 
 type ModerationResult struct {
-	ID           uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	ContentId    uuid.UUID     `gorm:"not null" json:"contentId"`
-	Content      Content       `gorm:"foreignKey:ContentId" json:"-"`
-	MediaType    MediaType     `gorm:"not null" json:"mediaType"`
-	Status       ContentStatus `gorm:"not null" json:"status"`
-	RiskScore    float64       `gorm:"not null" json:"riskScore"`
-	Explaination string        `json:"explanation"`
-	CreatedAt    time.Time     `json:"createdAt"`
+	ID                uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	ContentId         uuid.UUID     `gorm:"not null;index:idx_results_content_risk,priority:1" json:"contentId"`
+	Content           Content       `gorm:"foreignKey:ContentId" json:"-"`
+	MediaType         MediaType     `gorm:"not null" json:"mediaType"`
+	Status            ContentStatus `gorm:"not null" json:"status"`
+	RiskScore         float64       `gorm:"not null;index:idx_results_content_risk,priority:2" json:"riskScore"`
+	Category          Category      `gorm:"index" json:"category"`
+	Model             string        `gorm:"index" json:"model"`
+	PolicyVersion     string        `gorm:"index" json:"policyVersion"`
+	Explaination      string        `json:"explanation"`
+	ExplanationVector string        `gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(explaination, ''))) STORED;index:idx_results_explanation_search,type:gin" json:"-"`
+	CreatedAt         time.Time     `json:"createdAt"`
 }
 
 type ModerationEvents struct {
-	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	ContentId uuid.UUID      `gorm:"not null" json:"contentId"`
+	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	ContentId uuid.UUID      `gorm:"not null;index:idx_events_content_created,priority:1" json:"contentId"`
 	Content   Content        `gorm:"foreignKey:ContentId" json:"-"`
 	EventType EventType      `gorm:"not null" json:"eventType"`
-	Payload   datatypes.JSON `gorm:"type:JSONB" json:"payload"`
-	CreatedAt time.Time      `json:"createdAt"`
+	MediaType MediaType      `json:"mediaType,omitempty"`
+	Queue     string         `json:"queue,omitempty"`
+	Status    ContentStatus  `json:"status,omitempty"`
+	Payload   datatypes.JSON `json:"payload"`
+	CreatedAt time.Time      `gorm:"index:idx_events_content_created,priority:2" json:"createdAt"`
 }
 
 type Audit struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	ContentId uuid.UUID `gorm:"not null" json:"contentId"`
-	Content   Content   `gorm:"foreignKey:ContentId" json:"-"`
-	Action    Action    `gorm:"not null" json:"action"`
+	ID                  uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	ContentId           uuid.UUID     `gorm:"not null;index" json:"contentId"`
+	Content             Content       `gorm:"foreignKey:ContentId" json:"-"`
+	Action              Action        `gorm:"not null" json:"action"`
+	Reason              string        `json:"reason"`
+	PreviousFinalStatus ContentStatus `json:"previousFinalStatus"`
+	TextStatus          ContentStatus `json:"textStatus"`
+	ImageStatus         ContentStatus `json:"imageStatus"`
+	VideoStatus         ContentStatus `json:"videoStatus"`
+	FinalStatus         ContentStatus `json:"finalStatus"`
+	CreatedAt           time.Time     `gorm:"index" json:"createdAt"`
+}
+
+type WebhookSubscription struct {
+	ID          uuid.UUID                         `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID    string                            `gorm:"not null;index" json:"tenantId"`
+	URL         string                            `gorm:"not null" json:"url"`
+	Description string                            `json:"description"`
+	Secret      string                            `gorm:"not null" json:"-"`
+	Events      datatypes.JSONSlice[WebhookEvent] `json:"events"`
+	Active      bool                              `gorm:"not null;default:true" json:"active"`
+	CreatedAt   time.Time                         `json:"createdAt"`
+	UpdatedAt   time.Time                         `json:"updatedAt"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID           `gorm:"type:uuid;primaryKey" json:"id"`
+	SubscriptionID uuid.UUID           `gorm:"not null;index" json:"subscriptionId"`
+	Subscription   WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	ContentId      uuid.UUID           `gorm:"index" json:"contentId"`
+	EventType      WebhookEvent        `gorm:"not null" json:"eventType"`
+	Payload        datatypes.JSON      `json:"payload"`
+	Status         DeliveryStatus      `gorm:"not null;index" json:"status"`
+	Attempts       int                 `gorm:"not null;default:0" json:"attempts"`
+	ResponseCode   int                 `json:"responseCode"`
+	LastError      string              `json:"lastError"`
+	DeliveredAt    *time.Time          `json:"deliveredAt"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	UpdatedAt      time.Time           `json:"updatedAt"`
+	WebhookAttempt []WebhookAttempt    `gorm:"foreignKey:DeliveryID" json:"attemptLog,omitempty"`
+}
+
+type WebhookAttempt struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DeliveryID   uuid.UUID `gorm:"not null;index" json:"deliveryId"`
+	ResponseCode int       `json:"responseCode"`
+	ResponseBody string    `json:"responseBody"`
+	Error        string    `json:"error"`
+	DurationMs   int64     `json:"durationMs"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type Batch struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID  string    `gorm:"not null;index" json:"tenantId"`
+	Total     int       `gorm:"not null" json:"total"`
+	Accepted  int       `gorm:"not null" json:"accepted"`
+	Rejected  int       `gorm:"not null" json:"rejected"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Export struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID    string         `gorm:"not null;index" json:"tenantId"`
+	Format      ExportFormat   `gorm:"not null" json:"format"`
+	Filters     datatypes.JSON `json:"filters"`
+	Status      ExportStatus   `gorm:"not null;index" json:"status"`
+	RowCount    int64          `json:"rowCount"`
+	SizeBytes   int64          `json:"sizeBytes"`
+	Location    string         `json:"-"`
+	Error       string         `json:"error,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	CompletedAt *time.Time     `json:"completedAt"`
+}
+
+type BlocklistEntry struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID  string    `gorm:"not null;uniqueIndex:idx_blocklist_tenant_term,priority:1" json:"tenantId"`
+	Term      string    `gorm:"not null;uniqueIndex:idx_blocklist_tenant_term,priority:2" json:"term"`
+	Category  Category  `gorm:"not null" json:"category"`
 	Reason    string    `json:"reason"`
 	CreatedAt time.Time `json:"createdAt"`
 }
+
+type Policy struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID    string    `gorm:"not null;uniqueIndex:idx_policies_tenant_media_version,priority:1" json:"tenantId"`
+	MediaType   MediaType `gorm:"not null;uniqueIndex:idx_policies_tenant_media_version,priority:2" json:"mediaType"`
+	Version     string    `gorm:"not null;uniqueIndex:idx_policies_tenant_media_version,priority:3" json:"version"`
+	Instruction string    `gorm:"not null" json:"instruction"`
+	Active      bool      `gorm:"not null;default:false;index" json:"active"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// IdempotencyKey remembers the response to a request made with an
+// Idempotency-Key header, so a retry gets the same answer instead of
+// creating the content again
+type IdempotencyKey struct {
+	TenantID string `gorm:"primaryKey" json:"tenantId"`
+	Key      string `gorm:"primaryKey" json:"key"`
+	// RequestHash fingerprints the method, path and body the key was first used with
+	RequestHash string `gorm:"not null" json:"requestHash"`
+	// StatusCode is 0 while the first request is still being handled
+	StatusCode   int       `gorm:"not null" json:"statusCode"`
+	ContentType  string    `json:"contentType"`
+	ResponseBody string    `json:"responseBody"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expiresAt"`
+}
+
+// ModelCall is one call to a moderation model, with the tokens it used and
+// its cost estimated from the configured prices
+type ModelCall struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID  string    `gorm:"not null;index:idx_model_calls_tenant_created,priority:1" json:"tenantId"`
+	ContentID uuid.UUID `gorm:"type:uuid;not null;index" json:"contentId"`
+	MediaType MediaType `gorm:"not null" json:"mediaType"`
+	Model     string    `gorm:"not null" json:"model"`
+	// InputTokens counts the prompt; OutputTokens the response and any thinking
+	InputTokens  int64 `gorm:"not null" json:"inputTokens"`
+	OutputTokens int64 `gorm:"not null" json:"outputTokens"`
+	// Cost is in US dollars
+	Cost      float64   `gorm:"not null" json:"cost"`
+	CreatedAt time.Time `gorm:"index:idx_model_calls_tenant_created,priority:2" json:"createdAt"`
+}
+
+// ModelUsageDay totals a tenant's calls to one model over a UTC day
+type ModelUsageDay struct {
+	TenantID     string    `gorm:"primaryKey" json:"tenantId"`
+	Day          time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Model        string    `gorm:"primaryKey" json:"model"`
+	Calls        int64     `gorm:"not null" json:"calls"`
+	InputTokens  int64     `gorm:"not null" json:"inputTokens"`
+	OutputTokens int64     `gorm:"not null" json:"outputTokens"`
+	Cost         float64   `gorm:"not null" json:"cost"`
+}
+
+// BudgetAlert records that a tenant's model spend reached its monthly
+// budget. There is at most one per tenant and month.
+type BudgetAlert struct {
+	TenantID string `gorm:"primaryKey" json:"tenantId"`
+	// Month is the UTC month as YYYY-MM
+	Month  string  `gorm:"primaryKey" json:"month"`
+	Budget float64 `gorm:"not null" json:"budget"`
+	// Cost is the month's spend when the alert was raised
+	Cost      float64   `gorm:"not null" json:"cost"`
+	CreatedAt time.Time `json:"createdAt"`
+}
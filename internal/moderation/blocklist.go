@@ -0,0 +1,33 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+)
+
+// BlocklistModel is recorded as the model of results decided by the blocklist
+const BlocklistModel = "blocklist"
+
+// MatchBlocklist returns the tenant's first blocklisted term found in text,
+// compared case-insensitively, or nil when the text is clean
+func MatchBlocklist(ctx context.Context, blocklist repository.BlocklistRepository, tenantID, text string) (*models.BlocklistEntry, error) {
+	// An empty tenant would list every tenant's entries
+	if tenantID == "" {
+		return nil, nil
+	}
+	entries, err := blocklist.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := strings.ToLower(text)
+	for _, entry := range entries {
+		if strings.Contains(lower, strings.ToLower(entry.Term)) {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,264 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/internal/tracing"
+	"github.com/Sreejit-Sengupto/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+var ErrContentNotFound = errors.New("content not found")
+
+// Submit stores new content, records its creation and queues it for
+// moderation. Content that couldn't be queued is removed again, so it isn't
+// left PENDING with no task to moderate it.
+func Submit(ctx context.Context, repos *repository.Repositories, content *models.Content) error {
+	_, dbSpan := tracing.Start(ctx, "db.create_content")
+	err := repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Contents.Create(ctx, content); err != nil {
+			return err
+		}
+		return tx.Events.Create(ctx, &models.ModerationEvents{
+			ContentId: content.ID,
+			EventType: models.Created,
+			Status:    models.Pending,
+		})
+	})
+	tracing.End(dbSpan, err)
+	if err != nil {
+		return fmt.Errorf("Failed to create content")
+	}
+
+	// Published first so subscribers see it before any result
+	stream.Publish(ctx, stream.Event{
+		Type:      stream.ContentCreated,
+		ContentID: content.ID,
+		TenantID:  content.TenantID,
+		Status:    models.Pending,
+	})
+
+	if err := Enqueue(ctx, *content); err != nil {
+		if delErr := repos.Contents.DeleteMany(ctx, []uuid.UUID{content.ID}); delErr != nil {
+			logging.FromContext(ctx).Error("failed to remove content that wasn't enqueued", "content_id", content.ID, "error", delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Enqueue pushes one moderation task per modality present on the content,
+// carrying the trace context of ctx into each task
+func Enqueue(ctx context.Context, content models.Content) (err error) {
+	ctx, span := tracing.Start(ctx, "enqueue_moderation", tracing.ContentID(content.ID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	return enqueueJobs(ctx, content)
+}
+
+// enqueueWorkers is how many contents of a batch are enqueued concurrently
+const enqueueWorkers = 8
+
+// EnqueueMany is Enqueue for a batch of contents, several at a time. It
+// returns one error per content, nil for those whose tasks were all enqueued.
+func EnqueueMany(ctx context.Context, contents []models.Content) []error {
+	ctx, span := tracing.Start(ctx, "enqueue_moderation_batch")
+	defer span.End()
+
+	errs := make([]error, len(contents))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(enqueueWorkers, len(contents)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = enqueueJobs(ctx, contents[i])
+			}
+		}()
+	}
+	for i := range contents {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	logging.FromContext(ctx).Info("enqueued batch", "contents", len(contents)-failed, "failed", failed)
+	return errs
+}
+
+// job is a moderation task and the queue it goes on
+type job struct {
+	task  *asynq.Task
+	queue string
+}
+
+// enqueueJobs pushes the task of each modality present on the content
+func enqueueJobs(ctx context.Context, content models.Content) error {
+	jobs, err := moderationJobs(ctx, content)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		info, err := workerClient.Client.EnqueueContext(ctx, job.task, asynq.Queue(job.queue))
+		if err != nil {
+			return fmt.Errorf("Failed to enqueue %s moderation task", job.queue)
+		}
+		logging.FromContext(ctx).Info("enqueued task", "task_id", info.ID, "queue", info.Queue)
+	}
+	return nil
+}
+
+// moderationJobs builds the task of each modality present on the content
+func moderationJobs(ctx context.Context, content models.Content) ([]job, error) {
+	task, err := tasks.NewTextDeliveryTask(ctx, content.ID, content.Text)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create text moderation task")
+	}
+	jobs := []job{{task: task, queue: tasks.QueueText}}
+
+	if content.Image != "" {
+		task, err := tasks.NewImageDeliveryTask(ctx, content.ID, content.Image)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create image moderation task")
+		}
+		jobs = append(jobs, job{task: task, queue: tasks.QueueImage})
+	}
+
+	if content.Video != "" {
+		task, err := tasks.NewVideoDeliveryTask(ctx, content.ID, content.Video)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create video moderation task")
+		}
+		jobs = append(jobs, job{task: task, queue: tasks.QueueVideo})
+	}
+	return jobs, nil
+}
+
+// Override is a reviewer's decision on every modality of a content item
+type Override struct {
+	ContentID   uuid.UUID
+	Reason      string
+	TextStatus  models.ContentStatus
+	ImageStatus models.ContentStatus
+	VideoStatus models.ContentStatus
+	FinalStatus models.ContentStatus
+}
+
+// ApplyOverride replaces the content's statuses with the reviewer's, audits the
+// change and notifies stream and webhook subscribers
+func ApplyOverride(ctx context.Context, repos *repository.Repositories, override Override) (models.Content, error) {
+	var content models.Content
+	err := repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		var err error
+		if content, err = tx.Contents.Lock(ctx, override.ContentID); err != nil {
+			return err
+		}
+
+		previousFinalStatus := content.FinalStatus
+		content.TextStatus = override.TextStatus
+		content.ImageStatus = override.ImageStatus
+		content.VideoStatus = override.VideoStatus
+		content.FinalStatus = override.FinalStatus
+		if err := tx.Contents.Save(ctx, &content); err != nil {
+			return fmt.Errorf("failed to update content: %w", err)
+		}
+
+		// The reviewer's verdicts are kept on the audit so they can be compared
+		// against the model's results later
+		if err := tx.Audits.Create(ctx, &models.Audit{
+			ContentId:           override.ContentID,
+			Action:              models.Overriden,
+			Reason:              override.Reason,
+			PreviousFinalStatus: previousFinalStatus,
+			TextStatus:          content.TextStatus,
+			ImageStatus:         content.ImageStatus,
+			VideoStatus:         content.VideoStatus,
+			FinalStatus:         content.FinalStatus,
+		}); err != nil {
+			return fmt.Errorf("failed to insert audit log: %w", err)
+		}
+
+		return tx.Events.Create(ctx, &models.ModerationEvents{
+			ContentId: content.ID,
+			EventType: models.HumanReviewed,
+			Status:    content.FinalStatus,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return content, ErrContentNotFound
+		}
+		return content, err
+	}
+
+	stream.Publish(ctx, stream.Event{
+		Type:      stream.ContentOverridden,
+		ContentID: content.ID,
+		TenantID:  content.TenantID,
+		Status:    content.FinalStatus,
+	})
+
+	if err := webhooks.Dispatch(ctx, repos.Webhooks, content.TenantID, content.ID, models.ContentOverridden, map[string]interface{}{
+		"content": content,
+		"reason":  override.Reason,
+	}); err != nil {
+		logging.FromContext(ctx).Error("failed to dispatch webhooks", "content_id", content.ID, "error", err)
+	}
+
+	return content, nil
+}
+
+// Remoderate clears the content's verdicts and queues it for moderation again.
+// Aggregation only fills in empty per-modality statuses, so they have to be
+// reset for the new results to count.
+func Remoderate(ctx context.Context, repos *repository.Repositories, contentID uuid.UUID, reason string) (models.Content, error) {
+	var content models.Content
+	err := repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		var err error
+		if content, err = tx.Contents.Lock(ctx, contentID); err != nil {
+			return err
+		}
+
+		content.TextStatus = ""
+		content.ImageStatus = ""
+		content.VideoStatus = ""
+		content.FinalStatus = models.Pending
+		if err := tx.Contents.Save(ctx, &content); err != nil {
+			return err
+		}
+
+		payload, _ := json.Marshal(map[string]string{"reason": reason})
+		return tx.Events.Create(ctx, &models.ModerationEvents{
+			ContentId: content.ID,
+			EventType: models.Remoderated,
+			Status:    models.Pending,
+			Payload:   payload,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return content, ErrContentNotFound
+		}
+		return content, err
+	}
+
+	return content, Enqueue(ctx, content)
+}
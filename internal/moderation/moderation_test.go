@@ -0,0 +1,36 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/repository/memory"
+)
+
+// TestSubmitRemovesContentItCouldNotEnqueue checks that content whose tasks
+// can't be queued isn't left behind PENDING
+func TestSubmitRemovesContentItCouldNotEnqueue(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	workerClient.InitDetachedClient()
+
+	content := &models.Content{TenantID: "acme", AuthorID: "author", Text: "hello"}
+	if err := Submit(ctx, repos, content); err == nil {
+		t.Fatal("Submit succeeded without a queue")
+	}
+
+	if _, err := repos.Contents.Get(ctx, content.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("got %v getting the content, want it removed", err)
+	}
+	events, err := repos.Events.ListByContent(ctx, content.ID)
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("got events %+v, want them removed with the content", events)
+	}
+}
@@ -0,0 +1,266 @@
+// Package provider asks the moderation models for verdicts. Every model sits
+// behind a circuit breaker, and when a modality's own model fails or its
+// circuit is open the configured fallback chain is tried in order: other
+// models, the local rules, or holding the modality PENDING for human review.
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/breaker"
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metering"
+	"github.com/Sreejit-Sengupto/internal/metrics"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/tracing"
+	"github.com/Sreejit-Sengupto/utils/gemini"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+)
+
+var (
+	cfg config.ProviderConfig
+
+	mu       sync.Mutex
+	breakers = map[string]*breaker.Breaker{}
+)
+
+// Init sets the breaker settings and the fallback chain
+func Init(c config.ProviderConfig) {
+	cfg = c
+}
+
+// Request is one modality of a content item to moderate
+type Request struct {
+	TenantID  string
+	ContentID uuid.UUID
+	MediaType models.MediaType
+	// Model is the modality's own model, tried before the fallback chain
+	Model       string
+	Instruction string
+	// Text is moderated for text, Image (JPEG bytes) for images
+	Text  string
+	Image []byte
+}
+
+type Verdict struct {
+	Status      models.ContentStatus `json:"status"`
+	RiskScore   float64              `json:"riskScore"`
+	Category    models.Category      `json:"category"`
+	Explanation string               `json:"explanation"`
+	// Model is what decided: a model name, rules or hold
+	Model string `json:"-"`
+}
+
+// Moderate returns the verdict of the first step of the chain that gives
+// one, starting with the request's own model. The error joins the failure of
+// every step when none does.
+func Moderate(ctx context.Context, repos *repository.Repositories, req Request) (Verdict, error) {
+	steps := append([]string{req.Model}, cfg.Fallback...)
+
+	var errs []error
+	for i, step := range steps {
+		if i > 0 && step == req.Model {
+			continue
+		}
+
+		var verdict Verdict
+		var err error
+		switch step {
+		case config.FallbackRules:
+			verdict, err = rules(req)
+		case config.FallbackHold:
+			verdict = hold()
+		default:
+			verdict, err = generate(ctx, repos, step, req)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", step, err))
+			continue
+		}
+
+		if i > 0 {
+			metrics.ModelFallbacks.WithLabelValues(string(req.MediaType), step).Inc()
+			logging.FromContext(ctx).Warn("moderated by fallback",
+				"media_type", req.MediaType, "step", step, "error", errors.Join(errs...))
+		}
+		verdict.Model = step
+		return verdict, nil
+	}
+	return Verdict{}, errors.Join(errs...)
+}
+
+// FallbackModels returns the models in the fallback chain
+func FallbackModels() []string {
+	var names []string
+	for _, step := range cfg.Fallback {
+		if step != config.FallbackRules && step != config.FallbackHold {
+			names = append(names, step)
+		}
+	}
+	return names
+}
+
+// Circuits returns the state of the breaker around every model called so far
+func Circuits() map[string]breaker.State {
+	mu.Lock()
+	defer mu.Unlock()
+
+	states := make(map[string]breaker.State, len(breakers))
+	for name, b := range breakers {
+		states[name] = b.State()
+	}
+	return states
+}
+
+// CheckCircuits fails while any model's circuit is open or half-open, for the
+// readiness report
+func CheckCircuits(ctx context.Context) error {
+	var tripped []string
+	for name, state := range Circuits() {
+		if state != breaker.Closed {
+			tripped = append(tripped, fmt.Sprintf("%s %s", name, state))
+		}
+	}
+	if len(tripped) == 0 {
+		return nil
+	}
+	slices.Sort(tripped)
+	return fmt.Errorf("model circuits not closed: %s", strings.Join(tripped, ", "))
+}
+
+// circuit returns the model's breaker, created on first use
+func circuit(model string) *breaker.Breaker {
+	mu.Lock()
+	defer mu.Unlock()
+
+	b, ok := breakers[model]
+	if !ok {
+		b = breaker.New(model, cfg.FailureThreshold, cfg.OpenTimeout.Std())
+		breakers[model] = b
+	}
+	return b
+}
+
+// generate asks model for a verdict through its breaker, and meters the call
+// whenever the model answered
+func generate(ctx context.Context, repos *repository.Repositories, model string, req Request) (Verdict, error) {
+	var verdict Verdict
+
+	b := circuit(model)
+	token, err := b.Allow()
+	if err != nil {
+		return verdict, err
+	}
+
+	var contents []*genai.Content
+	if req.MediaType == models.Img {
+		contents = []*genai.Content{
+			genai.NewContentFromParts([]*genai.Part{genai.NewPartFromBytes(req.Image, "image/jpeg")}, genai.RoleUser),
+		}
+	} else {
+		contents = genai.Text(req.Text)
+	}
+
+	modelCtx, modelSpan := tracing.Start(ctx, "model.generate", tracing.Model(model))
+	start := time.Now()
+	response, err := gemini.GeminiClient.Models.GenerateContent(modelCtx, model, contents, generateConfig(req.Instruction))
+	metrics.ObserveModelCall(model, time.Since(start), err)
+	tracing.End(modelSpan, err)
+	if err != nil {
+		b.Done(token, outcome(err))
+		return verdict, fmt.Errorf("gemini.GeminiClient.Models.GenerateContent failed: %v", err)
+	}
+
+	metering.Record(ctx, repos, metering.Call{
+		TenantID:  req.TenantID,
+		ContentID: req.ContentID,
+		MediaType: req.MediaType,
+		Model:     model,
+		Usage:     response.UsageMetadata,
+	})
+
+	// An answer that isn't the verdict asked for is a failure of the model
+	if err := json.Unmarshal([]byte(response.Text()), &verdict); err != nil {
+		b.Done(token, breaker.Failure)
+		return verdict, fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+	b.Done(token, breaker.Success)
+	return verdict, nil
+}
+
+// outcome tells the circuit what a failed call says about the model. Server
+// errors, rate limiting and calls that never got an answer mean it is
+// unavailable; any other refusal is about this request and is neutral.
+func outcome(err error) breaker.Outcome {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code >= http.StatusInternalServerError || apiErr.Code == http.StatusTooManyRequests {
+			return breaker.Failure
+		}
+		return breaker.Neutral
+	}
+	return breaker.Failure
+}
+
+// generateConfig asks for a verdict as JSON under the given instruction
+func generateConfig(instruction string) *genai.GenerateContentConfig {
+	// JSON Schema for structured output
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"status": {
+				Type: genai.TypeString,
+				Enum: []string{"APPROVED", "REJECTED", "FLAGGED"},
+			},
+			"riskScore": {
+				Type:        genai.TypeNumber,
+				Description: "A score between 0 and 1 indicating the risk level",
+			},
+			"category": {
+				Type:        genai.TypeString,
+				Enum:        categoryEnum(),
+				Description: "The main policy category the content falls under, NONE when it is safe",
+			},
+			"explanation": {
+				Type:        genai.TypeString,
+				Description: "A brief explanation of the moderation decision",
+			},
+		},
+		Required: []string{"status", "riskScore", "category", "explanation"},
+	}
+
+	return &genai.GenerateContentConfig{
+		Temperature:       genai.Ptr(float32(0)),
+		ResponseMIMEType:  "application/json",
+		ResponseSchema:    schema,
+		SystemInstruction: genai.NewContentFromText(instruction, genai.RoleUser),
+	}
+}
+
+func categoryEnum() []string {
+	enum := make([]string, len(models.Categories))
+	for i, c := range models.Categories {
+		enum[i] = string(c)
+	}
+	return enum
+}
+
+// hold leaves the modality PENDING, which keeps the content item PENDING
+// until a reviewer overrides it or it is remoderated
+func hold() Verdict {
+	return Verdict{
+		Status:      models.Pending,
+		Explanation: "No moderation model was available; held for human review",
+	}
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+const (
+	// maxLinks is how many links make an item look like spam
+	maxLinks = 3
+	// maxRepeats is the longest run of one character allowed
+	maxRepeats = 20
+)
+
+var errRulesText = errors.New("rules only moderate text")
+
+// rules moderates text with local heuristics, flagging what looks like spam
+// for a reviewer rather than rejecting it. They only catch spam, so anything
+// else is approved without being understood: they trade accuracy for keeping
+// the pipeline moving, and belong after the models in the fallback chain.
+func rules(req Request) (Verdict, error) {
+	if req.MediaType != models.Txt {
+		return Verdict{}, errRulesText
+	}
+
+	if links := len(linkPattern.FindAllStringIndex(req.Text, -1)); links >= maxLinks {
+		return Verdict{
+			Status:      models.Flagged,
+			RiskScore:   0.6,
+			Category:    models.Spam,
+			Explanation: "Contains many links; flagged by the local rules while no model was available",
+		}, nil
+	}
+	if longestRun(req.Text) > maxRepeats {
+		return Verdict{
+			Status:      models.Flagged,
+			RiskScore:   0.5,
+			Category:    models.Spam,
+			Explanation: "Repeats one character at length; flagged by the local rules while no model was available",
+		}, nil
+	}
+	return Verdict{
+		Status:      models.Approved,
+		RiskScore:   0.1,
+		Category:    models.NoCategory,
+		Explanation: "Nothing matched the local rules, used while no model was available",
+	}, nil
+}
+
+// longestRun returns the length of the longest run of one rune in s
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, r := range s {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
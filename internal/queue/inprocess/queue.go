@@ -0,0 +1,422 @@
+// Package inprocess is a task queue that lives in the memory of one process.
+// It stands in for asynq and Redis when the API and the workers run together,
+// with the same queue priorities, retries and task metadata. Pending tasks are
+// lost when the process exits.
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// Defaults asynq applies when a task doesn't set them
+const (
+	defaultQueue    = "default"
+	defaultMaxRetry = 25
+	defaultTimeout  = 30 * time.Minute
+)
+
+// pollInterval bounds how long an idle worker waits before checking for
+// scheduled tasks that have come due
+const pollInterval = time.Second
+
+var errClosed = errors.New("in-process queue is closed")
+
+type task struct {
+	id       string
+	typename string
+	payload  []byte
+	queue    string
+	maxRetry int
+	retried  int
+	timeout  time.Duration
+	deadline time.Time
+	lastErr  string
+	// processAt is when a scheduled or retrying task becomes pending
+	processAt time.Time
+	// pendingSince is when the task last became pending, for queue latency
+	pendingSince time.Time
+}
+
+// counts are the per-queue numbers reported through GetQueueInfo
+type counts struct {
+	active    int
+	archived  int
+	processed int
+	failed    int
+}
+
+// Queue is both the client tasks are enqueued to and the server that
+// processes them
+type Queue struct {
+	weights     map[string]int
+	names       []string
+	concurrency int
+
+	mu        sync.Mutex
+	pending   map[string][]*task
+	scheduled []*task
+	counts    map[string]*counts
+	// ids holds the tasks not yet completed or archived, to reject duplicate IDs
+	ids     map[string]bool
+	closed  bool
+	started bool
+
+	// wake tells an idle worker that a task became pending
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New returns a queue that processes cfg.Queues by weight with
+// cfg.Concurrency workers once started
+func New(cfg config.WorkerConfig) *Queue {
+	return &Queue{
+		weights:     cfg.Queues,
+		names:       cfg.QueueNames(),
+		concurrency: cfg.Concurrency,
+		pending:     map[string][]*task{},
+		counts:      map[string]*counts{},
+		ids:         map[string]bool{},
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+func (q *Queue) Enqueue(t *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	return q.EnqueueContext(context.Background(), t, opts...)
+}
+
+// EnqueueContext accepts the same options as asynq.Client. Unique, Group and
+// Retention are ignored since nothing outlives the process.
+func (q *Queue) EnqueueContext(ctx context.Context, t *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	msg := &task{
+		id:       uuid.NewString(),
+		typename: t.Type(),
+		payload:  t.Payload(),
+		queue:    defaultQueue,
+		maxRetry: defaultMaxRetry,
+	}
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.QueueOpt:
+			msg.queue = opt.Value().(string)
+		case asynq.MaxRetryOpt:
+			msg.maxRetry = max(opt.Value().(int), 0)
+		case asynq.TaskIDOpt:
+			msg.id = opt.Value().(string)
+		case asynq.TimeoutOpt:
+			msg.timeout = opt.Value().(time.Duration)
+		case asynq.DeadlineOpt:
+			msg.deadline = opt.Value().(time.Time)
+		case asynq.ProcessAtOpt:
+			msg.processAt = opt.Value().(time.Time)
+		case asynq.ProcessInOpt:
+			msg.processAt = now.Add(opt.Value().(time.Duration))
+		}
+	}
+	if msg.timeout == 0 && msg.deadline.IsZero() {
+		msg.timeout = defaultTimeout
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, errClosed
+	}
+	if q.ids[msg.id] {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	q.ids[msg.id] = true
+
+	state := asynq.TaskStatePending
+	if msg.processAt.After(now) {
+		state = asynq.TaskStateScheduled
+		q.scheduled = append(q.scheduled, msg)
+	} else {
+		q.push(msg, now)
+	}
+	return msg.info(state), nil
+}
+
+// push makes the task pending; callers hold q.mu
+func (q *Queue) push(t *task, now time.Time) {
+	t.pendingSince = now
+	q.pending[t.queue] = append(q.pending[t.queue], t)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Ping reports whether the queue still accepts tasks
+func (q *Queue) Ping() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return errClosed
+	}
+	return nil
+}
+
+// Close stops accepting tasks
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	return nil
+}
+
+// Start runs the workers in the background until Shutdown
+func (q *Queue) Start(handler asynq.Handler) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return errors.New("in-process queue already started")
+	}
+	if q.closed {
+		return errClosed
+	}
+	q.started = true
+
+	for range q.concurrency {
+		q.wg.Add(1)
+		go q.work(handler)
+	}
+	return nil
+}
+
+// Shutdown stops fetching tasks and waits for the ones being processed, then
+// closes the queue. Tasks still pending, scheduled or retrying are dropped.
+func (q *Queue) Shutdown() {
+	q.mu.Lock()
+	if !q.started {
+		q.closed = true
+		q.mu.Unlock()
+		return
+	}
+	q.started = false
+	q.mu.Unlock()
+
+	// Tasks being processed may still enqueue follow-ups until they finish
+	close(q.stop)
+	q.wg.Wait()
+
+	q.mu.Lock()
+	q.closed = true
+	dropped := len(q.scheduled)
+	for _, ts := range q.pending {
+		dropped += len(ts)
+	}
+	q.mu.Unlock()
+	if dropped > 0 {
+		slog.Warn("in-process queue dropped unprocessed tasks", "count", dropped)
+	}
+}
+
+func (q *Queue) work(handler asynq.Handler) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		t := q.next()
+		if t == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		q.finish(t, q.process(handler, t))
+	}
+}
+
+// next moves scheduled tasks that have come due to pending, then takes the
+// oldest pending task of a queue picked at random by weight, like asynq does
+// without strict priority. Queues missing from the config are never picked.
+func (q *Queue) next() *task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.scheduled = slices.DeleteFunc(q.scheduled, func(t *task) bool {
+		if t.processAt.After(now) {
+			return false
+		}
+		q.push(t, now)
+		return true
+	})
+
+	total := 0
+	for _, name := range q.names {
+		if len(q.pending[name]) > 0 {
+			total += q.weights[name]
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.IntN(total)
+	for _, name := range q.names {
+		if len(q.pending[name]) == 0 {
+			continue
+		}
+		if n >= q.weights[name] {
+			n -= q.weights[name]
+			continue
+		}
+		t := q.pending[name][0]
+		q.pending[name] = q.pending[name][1:]
+		q.count(name).active++
+
+		// Let another idle worker pick up what's left
+		if len(q.pending[name]) > 0 || total > q.weights[name] {
+			select {
+			case q.wake <- struct{}{}:
+			default:
+			}
+		}
+		return t
+	}
+	return nil
+}
+
+// process runs the handler with the task's deadline and metadata, turning a
+// panic into an error as asynq does
+func (q *Queue) process(handler asynq.Handler, t *task) (err error) {
+	deadline := t.deadline
+	if t.timeout > 0 {
+		if d := time.Now().Add(t.timeout); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = tasks.WithMetadata(ctx, tasks.Metadata{
+		ID:       t.id,
+		Queue:    t.queue,
+		Retried:  t.retried,
+		MaxRetry: t.maxRetry,
+	})
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic [recovered]: %v", r)
+		}
+	}()
+	return handler.ProcessTask(ctx, asynq.NewTask(t.typename, t.payload))
+}
+
+// finish records the outcome: a failed task is retried after asynq's default
+// backoff until it runs out of retries or returns SkipRetry, then archived
+func (q *Queue) finish(t *task, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c := q.count(t.queue)
+	c.active--
+	c.processed++
+
+	if err == nil || errors.Is(err, asynq.RevokeTask) {
+		delete(q.ids, t.id)
+		return
+	}
+
+	c.failed++
+	t.lastErr = err.Error()
+	if t.retried >= t.maxRetry || errors.Is(err, asynq.SkipRetry) {
+		c.archived++
+		delete(q.ids, t.id)
+		slog.Warn("in-process queue archived task", "task_id", t.id, "task_type", t.typename, "queue", t.queue, "retried", t.retried, "error", err)
+		return
+	}
+
+	delay := asynq.DefaultRetryDelayFunc(t.retried, err, asynq.NewTask(t.typename, t.payload))
+	t.retried++
+	t.processAt = time.Now().Add(delay)
+	q.scheduled = append(q.scheduled, t)
+}
+
+// count returns the counters of the queue; callers hold q.mu
+func (q *Queue) count(queue string) *counts {
+	c, ok := q.counts[queue]
+	if !ok {
+		c = &counts{}
+		q.counts[queue] = c
+	}
+	return c
+}
+
+// GetQueueInfo reports the queue the way asynq.Inspector does, so the same
+// gauges work with either backend
+func (q *Queue) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	info := &asynq.QueueInfo{
+		Queue:     queue,
+		Pending:   len(q.pending[queue]),
+		Timestamp: now,
+	}
+	if pending := q.pending[queue]; len(pending) > 0 {
+		info.Latency = now.Sub(pending[0].pendingSince)
+	}
+	for _, t := range q.scheduled {
+		if t.queue != queue {
+			continue
+		}
+		if t.retried > 0 {
+			info.Retry++
+		} else {
+			info.Scheduled++
+		}
+	}
+	if c, ok := q.counts[queue]; ok {
+		info.Active = c.active
+		info.Archived = c.archived
+		info.ProcessedTotal = c.processed
+		info.FailedTotal = c.failed
+	}
+	info.Size = info.Pending + info.Active + info.Scheduled + info.Retry + info.Archived
+	return info, nil
+}
+
+func (t *task) info(state asynq.TaskState) *asynq.TaskInfo {
+	return &asynq.TaskInfo{
+		ID:            t.id,
+		Queue:         t.queue,
+		Type:          t.typename,
+		Payload:       t.payload,
+		State:         state,
+		MaxRetry:      t.maxRetry,
+		Retried:       t.retried,
+		LastErr:       t.lastErr,
+		Timeout:       t.timeout,
+		Deadline:      t.deadline,
+		NextProcessAt: t.processAt,
+	}
+}
@@ -1,61 +1,103 @@
 package queue
 
 import (
+	"fmt"
 	"log"
-	"os"
+	"log/slog"
+	"sync/atomic"
 
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
+	"github.com/Sreejit-Sengupto/internal/queue/inprocess"
 	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
 	"github.com/Sreejit-Sengupto/internal/queue/workers/aggregation"
+	"github.com/Sreejit-Sengupto/internal/queue/workers/export"
 	"github.com/Sreejit-Sengupto/internal/queue/workers/image"
 	"github.com/Sreejit-Sengupto/internal/queue/workers/text"
+	"github.com/Sreejit-Sengupto/internal/queue/workers/webhook"
+	"github.com/Sreejit-Sengupto/internal/repository"
 	"github.com/hibiken/asynq"
 )
 
-func StartWorkerServer(shutdown <-chan struct{}) {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		log.Fatal("REDIS_URL environment variable not set")
-		return
-	}
+// server processes queued tasks; *asynq.Server and *inprocess.Queue both implement it
+type server interface {
+	Start(handler asynq.Handler) error
+	Shutdown()
+	Ping() error
+}
 
-	// ParseRedisURI handles rediss:// URLs (with TLS) like Upstash
-	opt, err := asynq.ParseRedisURI(redisURL)
-	if err != nil {
-		log.Fatalf("Failed to parse REDIS_URL: %v", err)
-		return
+// running is the worker server of this process, nil until it has started
+var running atomic.Pointer[server]
+
+// inProcess is the queue shared by the client and the worker server of this
+// process with the memory backend, nil with Redis
+var inProcess *inprocess.Queue
+
+// InitInProcess creates the in-process queue, points the task client at it
+// and reports its depth on /metrics. The worker server started afterwards
+// processes it instead of Redis.
+func InitInProcess(cfg config.WorkerConfig) {
+	inProcess = inprocess.New(cfg)
+	workerClient.InitInProcessClient(inProcess)
+	metrics.RegisterQueueMetrics(inProcess, cfg.QueueNames())
+}
+
+// Ping checks that this process's worker server is running and can reach its queue
+func Ping() error {
+	srv := running.Load()
+	if srv == nil {
+		return fmt.Errorf("worker server not running")
 	}
+	return (*srv).Ping()
+}
+
+func StartWorkerServer(cfg config.WorkerConfig, redisCfg config.RedisConfig, repos *repository.Repositories, shutdown <-chan struct{}) {
+	var srv server
+	if inProcess != nil {
+		srv = inProcess
+	} else {
+		// ParseRedisURI handles rediss:// URLs (with TLS) like Upstash
+		opt, err := asynq.ParseRedisURI(redisCfg.URL)
+		if err != nil {
+			log.Fatalf("Failed to parse REDIS_URL: %v", err)
+			return
+		}
 
-	srv := asynq.NewServer(
-		opt,
-		asynq.Config{
-			Concurrency: 10,
-			Queues: map[string]int{
-				"text":        5,
-				"image":       3,
-				"video":       1,
-				"aggregation": 1,
+		srv = asynq.NewServer(
+			opt,
+			asynq.Config{
+				Concurrency: cfg.Concurrency,
+				Logger:      logging.AsynqLogger(),
+				Queues:      cfg.Queues,
 			},
-		},
-	)
+		)
+	}
 
 	mux := asynq.NewServeMux()
-	mux.HandleFunc(tasks.TypeTextDelivery, text.HandleTextDelivery)
-	mux.HandleFunc(tasks.TypeImageDelivery, image.HandleImageDelivery)
+	mux.Use(logging.TaskMiddleware, metrics.TaskMiddleware)
+	mux.HandleFunc(tasks.TypeTextDelivery, text.NewHandler(repos).HandleTextDelivery)
+	mux.HandleFunc(tasks.TypeImageDelivery, image.NewHandler(repos).HandleImageDelivery)
 	// mux.HandleFunc(tasks.TypeVideoDelivery, text.HandleVideoDelivery)
-	mux.HandleFunc(tasks.TypeAggregationDelivery, aggregation.HandleAggregationDelivery)
+	mux.HandleFunc(tasks.TypeAggregationDelivery, aggregation.NewHandler(repos).HandleAggregationDelivery)
+	mux.HandleFunc(tasks.TypeWebhookDelivery, webhook.NewHandler(repos).HandleWebhookDelivery)
+	mux.HandleFunc(tasks.TypeExportDelivery, export.NewHandler(repos).HandleExportDelivery)
 
-	log.Println("Starting Asynq worker server...")
+	slog.Info("starting worker server", "in_process", inProcess != nil)
 
-	// Start the server in a goroutine
-	go func() {
-		if err := srv.Run(mux); err != nil {
-			log.Printf("Asynq worker server stopped: %v", err)
-		}
-	}()
+	// Start runs the processors in the background; shutdown is driven by
+	// the channel below rather than asynq's own signal handling
+	if err := srv.Start(mux); err != nil {
+		slog.Error("worker server failed to start", "error", err)
+		return
+	}
+	running.Store(&srv)
 
 	// Wait for shutdown signal
 	<-shutdown
-	log.Println("Asynq worker server shutting down...")
+	slog.Info("worker server shutting down")
+	running.Store(nil)
 	srv.Shutdown()
-	log.Println("Asynq worker server stopped")
+	slog.Info("worker server stopped")
 }
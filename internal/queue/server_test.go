@@ -0,0 +1,247 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sreejit-Sengupto/api/handlers"
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/provider"
+	"github.com/Sreejit-Sengupto/internal/queue/inprocess"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	worktext "github.com/Sreejit-Sengupto/internal/queue/workers/text"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/repository/memory"
+	"github.com/Sreejit-Sengupto/utils/gemini"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+)
+
+// startWorkers runs the workers on the in-process queue until the test ends
+func startWorkers(t *testing.T, repos *repository.Repositories, fallback ...string) {
+	t.Helper()
+
+	providerCfg := config.Default().Provider
+	providerCfg.Fallback = fallback
+	provider.Init(providerCfg)
+
+	cfg := config.Default().Worker
+	// InitInProcess without the queue metrics, which only register once
+	inProcess = inprocess.New(cfg)
+	workerClient.InitInProcessClient(inProcess)
+	shutdown := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		StartWorkerServer(cfg, config.RedisConfig{}, repos, shutdown)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		close(shutdown)
+		<-stopped
+		inProcess = nil
+	})
+}
+
+// fakeModel points the Gemini client at a server answering every call with
+// status and body
+func fakeModel(t *testing.T, status int, body string) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to create Gemini client: %v", err)
+	}
+	gemini.GeminiClient = client
+	t.Cleanup(func() {
+		gemini.GeminiClient = nil
+		srv.Close()
+	})
+}
+
+// verdictResponse is a model answer carrying verdict as its text
+func verdictResponse(t *testing.T, verdict provider.Verdict) string {
+	t.Helper()
+
+	text, err := json.Marshal(verdict)
+	if err != nil {
+		t.Fatalf("failed to encode verdict: %v", err)
+	}
+	res, err := json.Marshal(map[string]any{
+		"candidates": []any{map[string]any{
+			"content":      map[string]any{"role": "model", "parts": []any{map[string]any{"text": string(text)}}},
+			"finishReason": "STOP",
+		}},
+		"usageMetadata": map[string]any{"promptTokenCount": 10, "candidatesTokenCount": 5},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode model response: %v", err)
+	}
+	return string(res)
+}
+
+// upload posts body to UploadContent as tenantID and returns the stored content
+func upload(t *testing.T, repos *repository.Repositories, tenantID, body string) models.Content {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload/content", strings.NewReader(body))
+	req.Header.Set(tenant.Header, tenantID)
+	rec := httptest.NewRecorder()
+	handlers.New(repos, handlers.Options{}).UploadContent(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload returned %d: %s", rec.Code, rec.Body)
+	}
+	var uploaded models.Content
+	if err := json.NewDecoder(rec.Body).Decode(&uploaded); err != nil {
+		t.Fatalf("failed to decode upload response: %v", err)
+	}
+	return uploaded
+}
+
+// awaitFinalStatus polls the content until its final status is want, and
+// fails the test when it isn't within a few seconds
+func awaitFinalStatus(t *testing.T, repos *repository.Repositories, id uuid.UUID, want models.ContentStatus) models.Content {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, err := repos.Contents.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("failed to get content: %v", err)
+		}
+		if content.FinalStatus == want {
+			return content
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("content %s has final status %q, want %q", id, content.FinalStatus, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// singleResult returns the only moderation result of the content
+func singleResult(t *testing.T, repos *repository.Repositories, id uuid.UUID) models.ModerationResult {
+	t.Helper()
+
+	results, err := repos.Results.ListByContent(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to list results: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1: %+v", len(results), results)
+	}
+	return results[0]
+}
+
+// TestUploadIsModeratedAndAggregated follows an upload through the text
+// worker and the aggregation worker on the in-process queue. The text is
+// blocklisted so no model is called.
+func TestUploadIsModeratedAndAggregated(t *testing.T) {
+	repos := memory.New()
+	if err := repos.Blocklist.Create(context.Background(), &models.BlocklistEntry{TenantID: "acme", Term: "forbidden", Category: models.Spam}); err != nil {
+		t.Fatalf("failed to create blocklist entry: %v", err)
+	}
+	startWorkers(t, repos)
+
+	uploaded := upload(t, repos, "acme", `{"text":"this is Forbidden","authorId":"author"}`)
+	content := awaitFinalStatus(t, repos, uploaded.ID, models.Rejected)
+
+	if content.TextStatus != models.Rejected {
+		t.Fatalf("got text status %q, want %q", content.TextStatus, models.Rejected)
+	}
+	if result := singleResult(t, repos, uploaded.ID); result.Category != models.Spam {
+		t.Fatalf("got result %+v, want a spam rejection", result)
+	}
+}
+
+// TestModelVerdictIsAggregated has the model approve an upload and checks the
+// verdict and the model call are recorded
+func TestModelVerdictIsAggregated(t *testing.T) {
+	repos := memory.New()
+	fakeModel(t, http.StatusOK, verdictResponse(t, provider.Verdict{
+		Status:      models.Approved,
+		RiskScore:   0.1,
+		Category:    models.Categories[0],
+		Explanation: "harmless",
+	}))
+	startWorkers(t, repos, config.FallbackHold)
+
+	uploaded := upload(t, repos, "acme", `{"text":"hello there","authorId":"author"}`)
+	awaitFinalStatus(t, repos, uploaded.ID, models.Approved)
+
+	result := singleResult(t, repos, uploaded.ID)
+	if result.Model != worktext.Model || result.PolicyVersion != worktext.PolicyVersion {
+		t.Fatalf("got result by %q under %q, want %q under %q", result.Model, result.PolicyVersion, worktext.Model, worktext.PolicyVersion)
+	}
+
+	calls, err := repos.Usage.ListByContent(context.Background(), uploaded.ID)
+	if err != nil {
+		t.Fatalf("failed to list model calls: %v", err)
+	}
+	if len(calls) != 1 || calls[0].TenantID != "acme" || calls[0].InputTokens != 10 || calls[0].OutputTokens != 5 {
+		t.Fatalf("got model calls %+v, want one of 10 input and 5 output tokens for acme", calls)
+	}
+}
+
+// TestModelOutageFallsBack has the model fail and checks the fallback chain
+// decides instead
+func TestModelOutageFallsBack(t *testing.T) {
+	repos := memory.New()
+	fakeModel(t, http.StatusServiceUnavailable, `{"error":{"code":503,"message":"overloaded","status":"UNAVAILABLE"}}`)
+	startWorkers(t, repos, config.FallbackRules)
+
+	uploaded := upload(t, repos, "acme", `{"text":"hello there","authorId":"author"}`)
+	awaitFinalStatus(t, repos, uploaded.ID, models.Approved)
+
+	if result := singleResult(t, repos, uploaded.ID); result.Model != config.FallbackRules {
+		t.Fatalf("got result by %q, want %q", result.Model, config.FallbackRules)
+	}
+}
+
+// TestBatchIsModerated uploads a batch with one invalid item and checks the
+// others all reach a final status
+func TestBatchIsModerated(t *testing.T) {
+	repos := memory.New()
+	if err := repos.Blocklist.Create(context.Background(), &models.BlocklistEntry{TenantID: "acme", Term: "forbidden", Category: models.Spam}); err != nil {
+		t.Fatalf("failed to create blocklist entry: %v", err)
+	}
+	startWorkers(t, repos)
+
+	body := `{"items":[{"text":"forbidden one"},{"authorId":"no text"},{"text":"forbidden two"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/upload/batch", strings.NewReader(body))
+	req.Header.Set(tenant.Header, "acme")
+	rec := httptest.NewRecorder()
+	handlers.New(repos, handlers.Options{}).UploadBatch(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("batch upload returned %d: %s", rec.Code, rec.Body)
+	}
+	var result handlers.BatchResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode batch response: %v", err)
+	}
+	if result.Accepted != 2 || result.Rejected != 1 || result.Items[1].Error == "" {
+		t.Fatalf("got batch %+v, want the second item rejected", result)
+	}
+
+	for _, item := range result.Items {
+		if item.ID != nil {
+			awaitFinalStatus(t, repos, *item.ID, models.Rejected)
+		}
+	}
+}
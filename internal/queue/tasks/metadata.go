@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+)
+
+// Metadata describes the task a handler is running. asynq keeps it in the
+// context under a private key, so the in-process queue attaches its own copy
+// and the helpers below read whichever is present.
+type Metadata struct {
+	ID       string
+	Queue    string
+	Retried  int
+	MaxRetry int
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context carrying the task's metadata
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+func metadata(ctx context.Context) (Metadata, bool) {
+	m, ok := ctx.Value(metadataKey{}).(Metadata)
+	return m, ok
+}
+
+// TaskID returns the ID of the task being processed
+func TaskID(ctx context.Context) (string, bool) {
+	if id, ok := asynq.GetTaskID(ctx); ok {
+		return id, true
+	}
+	m, ok := metadata(ctx)
+	return m.ID, ok
+}
+
+// QueueName returns the queue the task being processed came from
+func QueueName(ctx context.Context) (string, bool) {
+	if queue, ok := asynq.GetQueueName(ctx); ok {
+		return queue, true
+	}
+	m, ok := metadata(ctx)
+	return m.Queue, ok
+}
+
+// RetryCount returns how many times the task has been retried so far
+func RetryCount(ctx context.Context) (int, bool) {
+	if n, ok := asynq.GetRetryCount(ctx); ok {
+		return n, true
+	}
+	m, ok := metadata(ctx)
+	return m.Retried, ok
+}
+
+// MaxRetry returns how many times the task may be retried
+func MaxRetry(ctx context.Context) (int, bool) {
+	if n, ok := asynq.GetMaxRetry(ctx); ok {
+		return n, true
+	}
+	m, ok := metadata(ctx)
+	return m.MaxRetry, ok
+}
@@ -1,9 +1,11 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
@@ -14,6 +16,8 @@ const (
 	TypeImageDelivery       = "image_delivery"
 	TypeVideoDelivery       = "video_delivery"
 	TypeAggregationDelivery = "aggregation"
+	TypeWebhookDelivery     = "webhook_delivery"
+	TypeExportDelivery      = "export_delivery"
 )
 
 // Queue names (used for queue assignment)
@@ -22,21 +26,27 @@ const (
 	QueueImage       = "image"
 	QueueVideo       = "video"
 	QueueAggregation = "aggregation"
+	QueueWebhook     = "webhook"
+	QueueExport      = "export"
 )
 
 type TextDeliveryPayload struct {
 	ContentID uuid.UUID
 	Text      string
+	// Trace carries the enqueuer's trace context so workers continue the same trace
+	Trace tracing.Carrier `json:",omitempty"`
 }
 
 type ImageDeliveryPayload struct {
 	ContentID uuid.UUID
 	Image     string
+	Trace     tracing.Carrier `json:",omitempty"`
 }
 
 type VideoDeliveryPayload struct {
 	ContentID uuid.UUID
 	Video     string
+	Trace     tracing.Carrier `json:",omitempty"`
 }
 
 type ResultAggregationPayload struct {
@@ -44,12 +54,22 @@ type ResultAggregationPayload struct {
 	TextStatus  *models.ContentStatus
 	ImageStatus *models.ContentStatus
 	VideoStatus *models.ContentStatus
+	Trace       tracing.Carrier `json:",omitempty"`
 }
 
-func NewTextDeliveryTask(contentId uuid.UUID, text string) (*asynq.Task, error) {
+type WebhookDeliveryPayload struct {
+	DeliveryID uuid.UUID
+}
+
+type ExportDeliveryPayload struct {
+	ExportID uuid.UUID
+}
+
+func NewTextDeliveryTask(ctx context.Context, contentId uuid.UUID, text string) (*asynq.Task, error) {
 	payload, err := json.Marshal(TextDeliveryPayload{
 		ContentID: contentId,
 		Text:      text,
+		Trace:     tracing.Inject(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -57,10 +77,11 @@ func NewTextDeliveryTask(contentId uuid.UUID, text string) (*asynq.Task, error)
 	return asynq.NewTask(TypeTextDelivery, payload), nil
 }
 
-func NewImageDeliveryTask(contentId uuid.UUID, image string) (*asynq.Task, error) {
+func NewImageDeliveryTask(ctx context.Context, contentId uuid.UUID, image string) (*asynq.Task, error) {
 	payload, err := json.Marshal(ImageDeliveryPayload{
 		ContentID: contentId,
 		Image:     image,
+		Trace:     tracing.Inject(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -68,10 +89,11 @@ func NewImageDeliveryTask(contentId uuid.UUID, image string) (*asynq.Task, error
 	return asynq.NewTask(TypeImageDelivery, payload), nil
 }
 
-func NewVideoDeliveryTask(contentId uuid.UUID, video string) (*asynq.Task, error) {
+func NewVideoDeliveryTask(ctx context.Context, contentId uuid.UUID, video string) (*asynq.Task, error) {
 	payload, err := json.Marshal(VideoDeliveryPayload{
 		ContentID: contentId,
 		Video:     video,
+		Trace:     tracing.Inject(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -79,15 +101,36 @@ func NewVideoDeliveryTask(contentId uuid.UUID, video string) (*asynq.Task, error
 	return asynq.NewTask(TypeVideoDelivery, payload), nil
 }
 
-func NewAggregationDeliveryTask(contentId uuid.UUID, textStatus *models.ContentStatus, imageStatus *models.ContentStatus, videoStatus *models.ContentStatus) (*asynq.Task, error) {
+func NewAggregationDeliveryTask(ctx context.Context, contentId uuid.UUID, textStatus *models.ContentStatus, imageStatus *models.ContentStatus, videoStatus *models.ContentStatus) (*asynq.Task, error) {
 	payload, err := json.Marshal(ResultAggregationPayload{
 		ContentID:   contentId,
 		TextStatus:  textStatus,
 		ImageStatus: imageStatus,
 		VideoStatus: videoStatus,
+		Trace:       tracing.Inject(ctx),
 	})
 	if err != nil {
 		return nil, err
 	}
 	return asynq.NewTask(TypeAggregationDelivery, payload), nil
 }
+
+func NewWebhookDeliveryTask(deliveryId uuid.UUID) (*asynq.Task, error) {
+	payload, err := json.Marshal(WebhookDeliveryPayload{
+		DeliveryID: deliveryId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeWebhookDelivery, payload), nil
+}
+
+func NewExportDeliveryTask(exportId uuid.UUID) (*asynq.Task, error) {
+	payload, err := json.Marshal(ExportDeliveryPayload{
+		ExportID: exportId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeExportDelivery, payload), nil
+}
@@ -1,30 +1,70 @@
 package workerClient
 
 import (
+	"context"
+	"errors"
 	"log"
-	"os"
+	"log/slog"
 
+	"github.com/Sreejit-Sengupto/internal/config"
 	"github.com/hibiken/asynq"
 )
 
-var Client *asynq.Client
+// Enqueuer puts tasks on a queue. *asynq.Client is the Redis implementation;
+// the in-process queue in internal/queue/inprocess is the other.
+type Enqueuer interface {
+	Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+	Ping() error
+	Close() error
+}
 
-func InitClient() {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		log.Fatal("REDIS_URL environment variable not set")
-		return
-	}
+var Client Enqueuer
+
+func InitClient(cfg config.RedisConfig) {
 
 	// ParseRedisURI handles rediss:// URLs (with TLS) like Upstash
-	opt, err := asynq.ParseRedisURI(redisURL)
+	opt, err := asynq.ParseRedisURI(cfg.URL)
 	if err != nil {
 		log.Fatalf("Failed to parse REDIS_URL: %v", err)
 		return
 	}
 
 	Client = asynq.NewClient(opt)
-	log.Println("Asynq client initialized")
+	slog.Info("asynq client initialized")
+}
+
+// InitInProcessClient enqueues to a queue that lives in this process
+func InitInProcessClient(q Enqueuer) {
+	Client = q
+	slog.Info("in-process queue client initialized")
+}
+
+// ErrDetached is returned by the detached client for every task
+var ErrDetached = errors.New("tasks can only be enqueued inside the server process with the memory queue backend")
+
+// InitDetachedClient is for processes, like modctl, that share the database
+// with a memory-backed server but can't reach its queue. Every enqueue fails.
+func InitDetachedClient() {
+	Client = detached{}
+}
+
+type detached struct{}
+
+func (detached) Enqueue(*asynq.Task, ...asynq.Option) (*asynq.TaskInfo, error) {
+	return nil, ErrDetached
+}
+
+func (detached) EnqueueContext(context.Context, *asynq.Task, ...asynq.Option) (*asynq.TaskInfo, error) {
+	return nil, ErrDetached
+}
+
+func (detached) Ping() error  { return ErrDetached }
+func (detached) Close() error { return nil }
+
+// Ping checks that the queue tasks are enqueued to is reachable
+func Ping() error {
+	return Client.Ping()
 }
 
 func CloseClient() {
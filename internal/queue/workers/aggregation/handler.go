@@ -5,31 +5,48 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
 	"github.com/Sreejit-Sengupto/internal/models"
 	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/internal/tracing"
+	"github.com/Sreejit-Sengupto/internal/webhooks"
 	"github.com/hibiken/asynq"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
-func HandleAggregationDelivery(ctx context.Context, t *asynq.Task) error {
-	fmt.Println("Processing aggregation delivery task")
+// Handler runs aggregation tasks against the given repositories
+type Handler struct {
+	repos *repository.Repositories
+}
+
+func NewHandler(repos *repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
+
+func (h *Handler) HandleAggregationDelivery(ctx context.Context, t *asynq.Task) (err error) {
+	logging.FromContext(ctx).Info("processing aggregation task")
 	var payload tasks.ResultAggregationPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	db := database.DB
+	ctx, span := tracing.Start(tracing.Extract(ctx, payload.Trace), "aggregation.aggregate", tracing.ContentID(payload.ContentID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	dbCtx, dbSpan := tracing.Start(ctx, "db.aggregate_transaction")
+
+	var (
+		aggregated    models.Content
+		statusChanged bool
+	)
 
 	// Use transaction with row-level locking to prevent race conditions
 	// when multiple aggregation tasks run concurrently for the same content
-	err := db.Transaction(func(tx *gorm.DB) error {
-		var existingContent models.Content
-
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where(&models.Content{ID: payload.ContentID}).
-			First(&existingContent).Error; err != nil {
+	err = h.repos.Transaction(dbCtx, func(tx *repository.Repositories) error {
+		existingContent, err := tx.Contents.Lock(dbCtx, payload.ContentID)
+		if err != nil {
 			return fmt.Errorf("failed to find content: %v", err)
 		}
 
@@ -95,18 +112,45 @@ func HandleAggregationDelivery(ctx context.Context, t *asynq.Task) error {
 			}
 		}
 
+		statusChanged = existingContent.FinalStatus != finalStatus
 		existingContent.FinalStatus = finalStatus
-		if err := tx.Save(&existingContent).Error; err != nil {
+		if err := tx.Contents.Save(dbCtx, &existingContent); err != nil {
 			return fmt.Errorf("failed to update content: %v", err)
 		}
 
-		fmt.Printf("Aggregation complete for content %s: final status = %s\n", payload.ContentID, finalStatus)
+		aggregatedEvent := models.ModerationEvents{
+			ContentId: existingContent.ID,
+			EventType: models.Aggregated,
+			Status:    finalStatus,
+		}
+		if err := tx.Events.Create(dbCtx, &aggregatedEvent); err != nil {
+			return fmt.Errorf("failed to record aggregation event: %v", err)
+		}
+
+		logging.FromContext(ctx).Info("aggregation complete", "final_status", finalStatus)
+		aggregated = existingContent
 		return nil
 	})
+	tracing.End(dbSpan, err)
 
 	if err != nil {
 		return fmt.Errorf("transaction failed: %v", err)
 	}
 
+	// Notify subscribers only once the new status is committed
+	if statusChanged {
+		metrics.Verdicts.WithLabelValues("FINAL", string(aggregated.FinalStatus)).Inc()
+		stream.Publish(ctx, stream.Event{
+			Type:      stream.FinalStatus,
+			ContentID: aggregated.ID,
+			TenantID:  aggregated.TenantID,
+			Status:    aggregated.FinalStatus,
+		})
+
+		if err := webhooks.Dispatch(ctx, h.repos.Webhooks, aggregated.TenantID, aggregated.ID, models.ContentFinalStatus, aggregated); err != nil {
+			logging.FromContext(ctx).Error("failed to dispatch webhooks", "error", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	exporter "github.com/Sreejit-Sengupto/internal/export"
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/hibiken/asynq"
+)
+
+// countingWriter tracks the size of the export as it is written
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Handler runs export tasks against the given repositories
+type Handler struct {
+	repos *repository.Repositories
+}
+
+func NewHandler(repos *repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
+
+func (h *Handler) HandleExportDelivery(ctx context.Context, t *asynq.Task) error {
+	logging.FromContext(ctx).Info("processing export task")
+	var payload tasks.ExportDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	exports := h.repos.Exports
+
+	job, err := exports.Get(ctx, payload.ExportID)
+	if err != nil {
+		return fmt.Errorf("failed to find export: %v: %w", err, asynq.SkipRetry)
+	}
+
+	fail := func(err error) error {
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+		if saveErr := exports.Save(ctx, &job); saveErr != nil {
+			logging.FromContext(ctx).Error("failed to update export", "error", saveErr)
+		}
+		return fmt.Errorf("export %s failed: %v: %w", job.ID, err, asynq.SkipRetry)
+	}
+
+	// The recorded parameters are parsed again so the export is reproducible from the row alone
+	var params map[string]string
+	if err := json.Unmarshal(job.Filters, &params); err != nil {
+		return fail(err)
+	}
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	filter, err := listing.ParseContentFilter(values)
+	if err != nil {
+		return fail(err)
+	}
+	// Exports only ever cover their own tenant's content, whatever was recorded
+	filter.TenantID = job.TenantID
+
+	job.Status = models.ExportRunning
+	if err := exports.Save(ctx, &job); err != nil {
+		return fmt.Errorf("failed to update export: %v", err)
+	}
+
+	location := fmt.Sprintf("%s/%s.%s", job.TenantID, job.ID, exporter.Extension(job.Format))
+	file, err := exporter.Store.Create(location)
+	if err != nil {
+		return fail(err)
+	}
+
+	out := &countingWriter{w: file}
+	rows, err := exporter.Write(ctx, h.repos.Contents, out, job.Format, filter)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	job.Status = models.ExportCompleted
+	job.RowCount = rows
+	job.SizeBytes = out.n
+	job.Location = location
+	job.CompletedAt = &now
+	// Retried, which writes the file again, rather than left RUNNING
+	if err := exports.Save(ctx, &job); err != nil {
+		return fmt.Errorf("failed to update export: %v", err)
+	}
+
+	logging.FromContext(ctx).Info("export completed", "rows", rows)
+	return nil
+}
@@ -3,111 +3,106 @@ package image
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 
-	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
 	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/provider"
 	"github.com/Sreejit-Sengupto/internal/queue/tasks"
 	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
-	"github.com/Sreejit-Sengupto/utils/gemini"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/internal/tracing"
 	"github.com/hibiken/asynq"
-	"google.golang.org/genai"
 )
 
-type ImageModerationResult struct {
-	Status      string  `json:"status"`
-	RiskScore   float64 `json:"riskScore"`
-	Explanation string  `json:"explanation"`
-}
-
 type eventPayload struct {
 	ImageURL string `json:"imageURL"`
 }
 
+// Model is the Gemini model used for image moderation
+const Model = "gemini-3-flash-preview"
+
+// PolicyVersion identifies the system instruction below; bump it whenever the instruction changes
+const PolicyVersion = "image-v1"
+
 const SystemInstruction = " You are an automated image content moderation system designed to evaluate user-submitted images for safety and policy compliance. Your role is to objectively assess the visual content of each image and determine whether it is suitable for publication on a public platform. You must analyze images for the presence of unsafe or prohibited visual material, including but not limited to violence, graphic injury, sexual or pornographic content, child exploitation, hate symbols, harassment, self-harm, illegal activities, extremist imagery, misleading or manipulated media, and other harmful or policy-violating elements. Your evaluation must be based only on what is visible in the image itself, without assuming intent, narrative context, or external metadata unless explicitly provided as part of the image. If an image clearly violates safety standards, it must be rejected. If an image is ambiguous, borderline, or context-dependent, it must be flagged for human review. If an image does not present any safety or policy concerns, it must be approved. Your decisions must be consistent, conservative, and explainable. Do not modify, enhance, censor, describe creatively, or interpret the image beyond safety evaluation. Do not provide advice, opinions, captions, or alternative representations. Your task is strictly limited to classification and moderation decision-making."
 
-func HandleImageDelivery(ctx context.Context, t *asynq.Task) error {
-	fmt.Println("Processing image moderation task")
+// Handler runs image moderation tasks against the given repositories
+type Handler struct {
+	repos *repository.Repositories
+}
+
+func NewHandler(repos *repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
+
+func (h *Handler) HandleImageDelivery(ctx context.Context, t *asynq.Task) (err error) {
+	logging.FromContext(ctx).Info("processing image moderation task")
 	var payload tasks.ImageDeliveryPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	// JSON Schema for structured output
-	schema := &genai.Schema{
-		Type: genai.TypeObject,
-		Properties: map[string]*genai.Schema{
-			"status": {
-				Type: genai.TypeString,
-				Enum: []string{"APPROVED", "REJECTED", "FLAGGED"},
-			},
-			"riskScore": {
-				Type:        genai.TypeNumber,
-				Description: "A score between 0 and 1 indicating the risk level",
-			},
-			"explanation": {
-				Type:        genai.TypeString,
-				Description: "A brief explanation of the moderation decision",
-			},
-		},
-		Required: []string{"status", "riskScore", "explanation"},
-	}
-
-	config := &genai.GenerateContentConfig{
-		Temperature:       genai.Ptr(float32(0)),
-		ResponseMIMEType:  "application/json",
-		ResponseSchema:    schema,
-		SystemInstruction: genai.NewContentFromText(SystemInstruction, genai.RoleUser),
-	}
+	ctx, span := tracing.Start(tracing.Extract(ctx, payload.Trace), "image.moderate", tracing.ContentID(payload.ContentID.String()))
+	defer func() { tracing.End(span, err) }()
 
-	// fetch image
-	imageRes, err := http.Get(payload.Image)
-	if err != nil {
-		return fmt.Errorf("http.Get failed: %v: %w", err, asynq.SkipRetry)
+	content, err := h.repos.Contents.Get(ctx, payload.ContentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Deleted since it was enqueued, e.g. dropped from a batch it failed to enqueue with
+		return fmt.Errorf("content %s not found: %w", payload.ContentID, asynq.SkipRetry)
 	}
-	defer imageRes.Body.Close()
-
-	imageBytes, err := io.ReadAll(imageRes.Body)
 	if err != nil {
-		return fmt.Errorf("io.ReadAll failed: %v: %w", err, asynq.SkipRetry)
+		return fmt.Errorf("h.repos.Contents.Get failed: %v", err)
 	}
 
-	parts := []*genai.Part{
-		genai.NewPartFromBytes(imageBytes, "image/jpeg"),
+	// A tenant's active policy replaces the built-in instruction
+	policy, err := h.repos.Policies.Active(ctx, content.TenantID, models.Img)
+	if err != nil {
+		return fmt.Errorf("h.repos.Policies.Active failed: %v", err)
 	}
-
-	contents := []*genai.Content{
-		genai.NewContentFromParts(parts, genai.RoleUser),
+	instruction, policyVersion := SystemInstruction, PolicyVersion
+	if policy != nil {
+		instruction, policyVersion = policy.Instruction, policy.Version
 	}
 
-	response, err := gemini.GeminiClient.Models.GenerateContent(
-		ctx,
-		"gemini-3-flash-preview",
-		contents,
-		config,
-	)
+	// fetch image
+	imageBytes, err := fetchImage(ctx, payload.Image)
 	if err != nil {
-		return fmt.Errorf("gemini.GeminiClient.Models.GenerateContent failed: %v: %w", err, asynq.SkipRetry)
+		return err
 	}
 
-	var result ImageModerationResult
-	if err := json.Unmarshal([]byte(response.Text()), &result); err != nil {
-		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	// Falls back along the configured chain when the model is unavailable
+	result, err := provider.Moderate(ctx, h.repos, provider.Request{
+		TenantID:    content.TenantID,
+		ContentID:   payload.ContentID,
+		MediaType:   models.Img,
+		Model:       Model,
+		Instruction: instruction,
+		Image:       imageBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("provider.Moderate failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	db := database.DB
+	dbCtx, dbSpan := tracing.Start(ctx, "db.write_result")
 
 	moderationResult := models.ModerationResult{
-		ContentId:    payload.ContentID,
-		MediaType:    models.MediaType(models.Img),
-		Status:       models.ContentStatus(result.Status),
-		RiskScore:    result.RiskScore,
-		Explaination: result.Explanation,
+		ContentId:     payload.ContentID,
+		MediaType:     models.MediaType(models.Img),
+		Status:        result.Status,
+		RiskScore:     result.RiskScore,
+		Category:      result.Category,
+		Explaination:  result.Explanation,
+		Model:         result.Model,
+		PolicyVersion: policyVersion,
 	}
-	db.Create(&moderationResult)
+	h.repos.Results.Create(dbCtx, &moderationResult)
+	metrics.Verdicts.WithLabelValues(string(moderationResult.MediaType), string(moderationResult.Status)).Inc()
 
 	modDataPayload := eventPayload{
 		ImageURL: payload.Image,
@@ -117,23 +112,59 @@ func HandleImageDelivery(ctx context.Context, t *asynq.Task) error {
 		return fmt.Errorf("json.Marshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
+	queueName, _ := tasks.QueueName(ctx)
 	moderationEventData := models.ModerationEvents{
 		ContentId: payload.ContentID,
 		EventType: models.EventType(models.Moderated),
+		MediaType: models.Img,
+		Queue:     queueName,
+		Status:    moderationResult.Status,
 		Payload:   modDataEventJson,
 	}
-	db.Create(&moderationEventData)
+	h.repos.Events.Create(dbCtx, &moderationEventData)
+
+	dbSpan.End()
+
+	stream.Publish(ctx, stream.Event{
+		Type:      stream.ModalityResult,
+		ContentID: payload.ContentID,
+		TenantID:  content.TenantID,
+		MediaType: models.Img,
+		Status:    moderationResult.Status,
+		RiskScore: &moderationResult.RiskScore,
+	})
 
-	status := models.ContentStatus(result.Status)
-	task, err := tasks.NewAggregationDeliveryTask(payload.ContentID, nil, &status, nil)
+	status := result.Status
+	task, err := tasks.NewAggregationDeliveryTask(ctx, payload.ContentID, nil, &status, nil)
 	if err != nil {
 		return fmt.Errorf("tasks.NewAggregationDeliveryTask failed: %v: %w", err, asynq.SkipRetry)
 	}
-	info, err := workerClient.Client.Enqueue(task, asynq.Queue(tasks.QueueAggregation))
+	info, err := workerClient.Client.EnqueueContext(ctx, task, asynq.Queue(tasks.QueueAggregation))
 	if err != nil {
 		return fmt.Errorf("workerClient.Client.Enqueue failed: %v: %w", err, asynq.SkipRetry)
 	}
-	log.Printf("enqueued task: id=%s queue=%s", info.ID, info.Queue)
+	logging.FromContext(ctx).Info("enqueued task", "task_id", info.ID, "queue", info.Queue)
 
 	return nil
 }
+
+func fetchImage(ctx context.Context, url string) (body []byte, err error) {
+	ctx, span := tracing.Start(ctx, "image.fetch")
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest failed: %v: %w", err, asynq.SkipRetry)
+	}
+	imageRes, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http.Get failed: %v: %w", err, asynq.SkipRetry)
+	}
+	defer imageRes.Body.Close()
+
+	body, err = io.ReadAll(imageRes.Body)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll failed: %v: %w", err, asynq.SkipRetry)
+	}
+	return body, nil
+}
@@ -3,90 +3,117 @@ package text
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 
-	"github.com/Sreejit-Sengupto/internal/database"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/metrics"
 	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/moderation"
+	"github.com/Sreejit-Sengupto/internal/provider"
 	"github.com/Sreejit-Sengupto/internal/queue/tasks"
 	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
-	"github.com/Sreejit-Sengupto/utils/gemini"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/stream"
+	"github.com/Sreejit-Sengupto/internal/tracing"
 	"github.com/hibiken/asynq"
-	"google.golang.org/genai"
 )
 
-type TextModerationResult struct {
-	Status      string  `json:"status"`
-	RiskScore   float64 `json:"riskScore"`
-	Explanation string  `json:"explanation"`
-}
-
 type eventPayload struct {
 	Text string `json:"text"`
 }
 
+// Model is the Gemini model used for text moderation
+const Model = "gemini-2.5-flash"
+
+// PolicyVersion identifies the system instruction below; bump it whenever the instruction changes
+const PolicyVersion = "text-v1"
+
 const SystemInstruction = "You are an automated content moderation system designed to evaluate user - generated content for safety and policy compliance. Your role is to assess the provided content objectively and determine whether it is acceptable for publication on a public platform. You must analyze the content for the presence of harmful, abusive, hateful, sexual, violent, illegal, self - harm, misleading, or otherwise unsafe material. You must make a moderation decision based solely on the content itself, without assuming user intent or external context. If the content clearly violates safety standards, it should be rejected. If the content is ambiguous, borderline, or context - dependent, it should be flagged for human review. If the content does not present safety concerns, it should be approved. Your decision should be consistent, conservative, and explainable. Do not attempt to rewrite, censor, summarize, or respond to the content. Do not provide advice, opinions, or alternative phrasing. Your task is strictly limited to evaluation and classification."
 
-func HandleTextDelivery(ctx context.Context, t *asynq.Task) error {
-	fmt.Println("Processing text moderation task")
+// Handler runs text moderation tasks against the given repositories
+type Handler struct {
+	repos *repository.Repositories
+}
+
+func NewHandler(repos *repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
+
+func (h *Handler) HandleTextDelivery(ctx context.Context, t *asynq.Task) (err error) {
+	logging.FromContext(ctx).Info("processing text moderation task")
 	var payload tasks.TextDeliveryPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	// JSON Schema for structured output
-	schema := &genai.Schema{
-		Type: genai.TypeObject,
-		Properties: map[string]*genai.Schema{
-			"status": {
-				Type: genai.TypeString,
-				Enum: []string{"APPROVED", "REJECTED", "FLAGGED"},
-			},
-			"riskScore": {
-				Type:        genai.TypeNumber,
-				Description: "A score between 0 and 1 indicating the risk level",
-			},
-			"explanation": {
-				Type:        genai.TypeString,
-				Description: "A brief explanation of the moderation decision",
-			},
-		},
-		Required: []string{"status", "riskScore", "explanation"},
+	ctx, span := tracing.Start(tracing.Extract(ctx, payload.Trace), "text.moderate", tracing.ContentID(payload.ContentID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	content, err := h.repos.Contents.Get(ctx, payload.ContentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Deleted since it was enqueued, e.g. dropped from a batch it failed to enqueue with
+		return fmt.Errorf("content %s not found: %w", payload.ContentID, asynq.SkipRetry)
+	}
+	if err != nil {
+		return fmt.Errorf("h.repos.Contents.Get failed: %v", err)
 	}
 
-	config := &genai.GenerateContentConfig{
-		Temperature:       genai.Ptr(float32(0)),
-		ResponseMIMEType:  "application/json",
-		ResponseSchema:    schema,
-		SystemInstruction: genai.NewContentFromText(SystemInstruction, genai.RoleUser),
+	// Blocklisted terms are rejected without asking the model
+	blocked, err := moderation.MatchBlocklist(ctx, h.repos.Blocklist, content.TenantID, payload.Text)
+	if err != nil {
+		return fmt.Errorf("moderation.MatchBlocklist failed: %v", err)
 	}
 
-	response, err := gemini.GeminiClient.Models.GenerateContent(
-		ctx,
-		"gemini-2.5-flash",
-		genai.Text(payload.Text),
-		config,
-	)
+	// A tenant's active policy replaces the built-in instruction
+	policy, err := h.repos.Policies.Active(ctx, content.TenantID, models.Txt)
 	if err != nil {
-		return fmt.Errorf("gemini.GeminiClient.Models.GenerateContent failed: %v: %w", err, asynq.SkipRetry)
+		return fmt.Errorf("h.repos.Policies.Active failed: %v", err)
+	}
+	instruction, policyVersion := SystemInstruction, PolicyVersion
+	if policy != nil {
+		instruction, policyVersion = policy.Instruction, policy.Version
 	}
 
-	var result TextModerationResult
-	if err := json.Unmarshal([]byte(response.Text()), &result); err != nil {
-		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	var result provider.Verdict
+	if blocked != nil {
+		result = provider.Verdict{
+			Status:      models.Rejected,
+			RiskScore:   1,
+			Category:    blocked.Category,
+			Explanation: fmt.Sprintf("Matched blocklisted term %q", blocked.Term),
+			Model:       moderation.BlocklistModel,
+		}
+	} else {
+		// Falls back along the configured chain when the model is unavailable
+		result, err = provider.Moderate(ctx, h.repos, provider.Request{
+			TenantID:    content.TenantID,
+			ContentID:   payload.ContentID,
+			MediaType:   models.Txt,
+			Model:       Model,
+			Instruction: instruction,
+			Text:        payload.Text,
+		})
+		if err != nil {
+			return fmt.Errorf("provider.Moderate failed: %v: %w", err, asynq.SkipRetry)
+		}
 	}
 
-	db := database.DB
+	dbCtx, dbSpan := tracing.Start(ctx, "db.write_result")
 
 	moderationResult := models.ModerationResult{
-		ContentId:    payload.ContentID,
-		MediaType:    models.MediaType(models.Txt),
-		Status:       models.ContentStatus(result.Status),
-		RiskScore:    result.RiskScore,
-		Explaination: result.Explanation,
+		ContentId:     payload.ContentID,
+		MediaType:     models.MediaType(models.Txt),
+		Status:        result.Status,
+		RiskScore:     result.RiskScore,
+		Category:      result.Category,
+		Explaination:  result.Explanation,
+		Model:         result.Model,
+		PolicyVersion: policyVersion,
 	}
 
-	db.Create(&moderationResult)
+	h.repos.Results.Create(dbCtx, &moderationResult)
+	metrics.Verdicts.WithLabelValues(string(moderationResult.MediaType), string(moderationResult.Status)).Inc()
 
 	modDataPayload := eventPayload{
 		Text: payload.Text,
@@ -96,25 +123,39 @@ func HandleTextDelivery(ctx context.Context, t *asynq.Task) error {
 		return fmt.Errorf("json.Marshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
+	queueName, _ := tasks.QueueName(ctx)
 	modEvent := models.ModerationEvents{
 		ContentId: payload.ContentID,
 		EventType: models.EventType(models.Moderated),
+		MediaType: models.Txt,
+		Queue:     queueName,
+		Status:    moderationResult.Status,
 		Payload:   modDataPayloadJson,
 	}
-	db.Create(&modEvent)
-
-	status := models.ContentStatus(result.Status)
-	task, err := tasks.NewAggregationDeliveryTask(payload.ContentID, &status, nil, nil)
+	h.repos.Events.Create(dbCtx, &modEvent)
+	dbSpan.End()
+
+	stream.Publish(ctx, stream.Event{
+		Type:      stream.ModalityResult,
+		ContentID: payload.ContentID,
+		TenantID:  content.TenantID,
+		MediaType: models.Txt,
+		Status:    moderationResult.Status,
+		RiskScore: &moderationResult.RiskScore,
+	})
+
+	status := result.Status
+	task, err := tasks.NewAggregationDeliveryTask(ctx, payload.ContentID, &status, nil, nil)
 	if err != nil {
 		return fmt.Errorf("tasks.NewAggregationDeliveryTask failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	info, err := workerClient.Client.Enqueue(task, asynq.Queue(tasks.QueueAggregation))
+	info, err := workerClient.Client.EnqueueContext(ctx, task, asynq.Queue(tasks.QueueAggregation))
 	if err != nil {
 		return fmt.Errorf("workerClient.Client.Enqueue failed: %v: %w", err, asynq.SkipRetry)
 	}
-	log.Printf("enqueued task: id=%s queue=%s", info.ID, info.Queue)
+	logging.FromContext(ctx).Info("enqueued task", "task_id", info.ID, "queue", info.Queue)
 
-	fmt.Println("Text processing completed")
+	logging.FromContext(ctx).Info("text moderation completed", "status", result.Status)
 	return nil
 }
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/webhooks"
+	"github.com/hibiken/asynq"
+)
+
+// Handler runs webhook delivery tasks against the given repositories
+type Handler struct {
+	repos *repository.Repositories
+}
+
+func NewHandler(repos *repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
+
+func (h *Handler) HandleWebhookDelivery(ctx context.Context, t *asynq.Task) error {
+	logging.FromContext(ctx).Info("processing webhook delivery task")
+	var payload tasks.WebhookDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	webhookRepo := h.repos.Webhooks
+
+	delivery, err := webhookRepo.GetDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook delivery: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if !delivery.Subscription.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "subscription is inactive"
+		if err := webhookRepo.SaveDelivery(ctx, &delivery); err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %v", err)
+		}
+		return nil
+	}
+
+	start := time.Now()
+	code, body, sendErr := webhooks.Send(ctx, delivery.Subscription, delivery)
+
+	attempt := models.WebhookAttempt{
+		DeliveryID:   delivery.ID,
+		ResponseCode: code,
+		ResponseBody: body,
+		DurationMs:   time.Since(start).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	// The attempt log is informational, the delivery itself is still updated
+	if err := webhookRepo.CreateAttempt(ctx, &attempt); err != nil {
+		logging.FromContext(ctx).Error("failed to record webhook attempt", "error", err)
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode = code
+
+	if sendErr == nil {
+		now := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		// Retried rather than left PENDING; receivers deduplicate the resend
+		// by its webhook-id
+		if err := webhookRepo.SaveDelivery(ctx, &delivery); err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %v", err)
+		}
+		logging.FromContext(ctx).Info("webhook delivery succeeded", "status_code", code)
+		return nil
+	}
+
+	// Once asynq has used up the retries the delivery stays FAILED until redelivered
+	retried, _ := tasks.RetryCount(ctx)
+	maxRetry, _ := tasks.MaxRetry(ctx)
+	delivery.LastError = sendErr.Error()
+	if retried >= maxRetry {
+		delivery.Status = models.DeliveryFailed
+	}
+	if err := webhookRepo.SaveDelivery(ctx, &delivery); err != nil {
+		logging.FromContext(ctx).Error("failed to update webhook delivery", "error", err)
+	}
+
+	return fmt.Errorf("webhook delivery %s failed: %v", delivery.ID, sendErr)
+}
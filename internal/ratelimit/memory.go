@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets and counters nobody uses are dropped
+const sweepInterval = time.Minute
+
+// memoryStore keeps the buckets and counters of this process, for running
+// without Redis
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+	// expires is when the bucket would be full again
+	expires time.Time
+}
+
+type memoryCounter struct {
+	n       int64
+	expires time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets:   map[string]*bucket{},
+		counters:  map[string]*memoryCounter{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memoryStore) take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), ts: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(burst), b.tokens+now.Sub(b.ts).Seconds()*rate)
+	b.ts = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.expires = now.Add(seconds(float64(burst) / rate))
+	return b.tokens, allowed, nil
+}
+
+func (s *memoryStore) consume(ctx context.Context, counters []counter, n int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range counters {
+		if c.limit > 0 && s.count(c.key)+n > c.limit {
+			return i, nil
+		}
+	}
+	for _, c := range counters {
+		mc, ok := s.counters[c.key]
+		if !ok {
+			mc = &memoryCounter{}
+			s.counters[c.key] = mc
+		}
+		mc.n += n
+		mc.expires = c.resetsAt
+	}
+	return -1, nil
+}
+
+func (s *memoryStore) refund(ctx context.Context, counters []counter, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range counters {
+		if mc, ok := s.counters[c.key]; ok {
+			mc.n = max(mc.n-n, 0)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) counts(ctx context.Context, counters []counter) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make([]int64, len(counters))
+	for i, c := range counters {
+		counts[i] = s.count(c.key)
+	}
+	return counts, nil
+}
+
+// count returns the counter's value; callers hold s.mu
+func (s *memoryStore) count(key string) int64 {
+	if mc, ok := s.counters[key]; ok {
+		return mc.n
+	}
+	return 0
+}
+
+// sweep drops what has expired, at most once per interval; callers hold s.mu
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.expires) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, mc := range s.counters {
+		if now.After(mc.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+func (s *memoryStore) ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *memoryStore) close() error {
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package ratelimit limits how fast API clients call the service, with a
+// token bucket per client, and how much content they submit, with daily and
+// monthly quotas per tenant or client. The counters live in Redis so every
+// API instance enforces the same limits, or in memory without Redis.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/utils/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces every key the limiter writes to Redis
+const keyPrefix = "ratelimit:"
+
+// Period is the window a quota counts submissions over
+type Period string
+
+const (
+	// 'day', 'month'
+	Day   Period = "day"
+	Month Period = "month"
+)
+
+// Decision is the state of a client's token bucket after a request
+type Decision struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the requests the client can still make right away
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, when it wasn't
+	RetryAfter time.Duration
+}
+
+// Quota is the consumption of one quota period
+type Quota struct {
+	Period Period `json:"period"`
+	// Limit is 0 when the period is unlimited
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining *int64    `json:"remaining,omitempty"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// QuotaDecision is the outcome of counting submissions against the quotas
+type QuotaDecision struct {
+	Allowed bool
+	// Exceeded is the quota that refused the submissions
+	Exceeded *Quota
+}
+
+// store keeps the buckets and counters
+type store interface {
+	take(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error)
+	// consume adds n to every counter if none would go over its limit, or
+	// returns the index of the first that would
+	consume(ctx context.Context, counters []counter, n int64) (exceeded int, err error)
+	refund(ctx context.Context, counters []counter, n int64) error
+	counts(ctx context.Context, counters []counter) ([]int64, error)
+	ping(ctx context.Context) error
+	close() error
+}
+
+// counter is one quota period's count, kept until the period ends
+type counter struct {
+	key      string
+	limit    int64
+	resetsAt time.Time
+}
+
+// Limiter enforces the configured limits
+type Limiter struct {
+	cfg   config.RateLimitConfig
+	store store
+}
+
+// New returns a limiter backed by Redis, or by this process's memory when no
+// Redis URL is configured, in which case each instance counts on its own
+func New(cfg config.RateLimitConfig, redisCfg config.RedisConfig) *Limiter {
+	if redisCfg.URL == "" {
+		slog.Warn("rate limits and quotas are kept per process, no Redis configured")
+		return &Limiter{cfg: cfg, store: newMemoryStore()}
+	}
+
+	opt, err := redis.ParseURL(redisCfg.URL)
+	if err != nil {
+		log.Fatalf("Failed to parse REDIS_URL: %v", err)
+	}
+	slog.Info("rate limiter client initialized")
+	return &Limiter{cfg: cfg, store: &redisStore{client: redis.NewClient(opt)}}
+}
+
+// Ping checks that the limiter's store is reachable
+func (l *Limiter) Ping(ctx context.Context) error {
+	return l.store.ping(ctx)
+}
+
+func (l *Limiter) Close() error {
+	return l.store.close()
+}
+
+// Client identifies the caller of r: a hash of its API key, so the key itself
+// isn't stored, or its IP address. Only keys the request was authenticated
+// with count, since anyone could send a new key with every request to get a
+// fresh bucket.
+func (l *Limiter) Client(r *http.Request) string {
+	if tenant.Authenticated(r) {
+		sum := sha256.Sum256([]byte(r.Header.Get(tenant.KeyHeader)))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+
+	if l.cfg.TrustProxy {
+		// The last address is the one the nearest proxy saw; earlier ones are
+		// whatever the client sent
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// QuotaSubject is the ID the submissions of r are counted against: the
+// tenant its content is written under or the client, depending on the
+// configured scope
+func (l *Limiter) QuotaSubject(r *http.Request) string {
+	if l.cfg.QuotaScope == config.QuotaClient {
+		return l.Client(r)
+	}
+	return tenant.FromRequest(r)
+}
+
+// Allow takes a token from the client's bucket
+func (l *Limiter) Allow(ctx context.Context, client string) (Decision, error) {
+	rate := float64(l.cfg.RequestsPerSecond)
+	burst := l.cfg.Burst
+	tokens, allowed, err := l.store.take(ctx, keyPrefix+"bucket:"+client, rate, burst)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(burst) - tokens) / rate),
+	}
+	if !allowed {
+		decision.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return decision, nil
+}
+
+// Consume counts n submissions against the subject's quotas, all or nothing
+func (l *Limiter) Consume(ctx context.Context, subject string, n int) (QuotaDecision, error) {
+	counters, quotas := l.counters(subject, time.Now())
+	exceeded, err := l.store.consume(ctx, counters, int64(n))
+	if err != nil {
+		return QuotaDecision{}, err
+	}
+	if exceeded < 0 {
+		return QuotaDecision{Allowed: true}, nil
+	}
+
+	used, err := l.store.counts(ctx, counters[exceeded:exceeded+1])
+	if err != nil {
+		return QuotaDecision{}, err
+	}
+	quota := quotas[exceeded]
+	quota.setUsed(used[0])
+	return QuotaDecision{Exceeded: &quota}, nil
+}
+
+// Refund gives back submissions that were counted but didn't go through
+func (l *Limiter) Refund(ctx context.Context, subject string, n int) error {
+	counters, _ := l.counters(subject, time.Now())
+	return l.store.refund(ctx, counters, int64(n))
+}
+
+// Usage reports the subject's consumption of each quota period
+func (l *Limiter) Usage(ctx context.Context, subject string) ([]Quota, error) {
+	counters, quotas := l.counters(subject, time.Now())
+	used, err := l.store.counts(ctx, counters)
+	if err != nil {
+		return nil, err
+	}
+	for i := range quotas {
+		quotas[i].setUsed(used[i])
+	}
+	return quotas, nil
+}
+
+// Bucket describes the token bucket every client gets
+func (l *Limiter) Bucket() (requestsPerSecond, burst int) {
+	return l.cfg.RequestsPerSecond, l.cfg.Burst
+}
+
+// counters returns the subject's counters for the periods containing now.
+// Periods follow the UTC calendar.
+func (l *Limiter) counters(subject string, now time.Time) ([]counter, []Quota) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	dailyLimit := l.cfg.DailyQuota
+	if n, ok := l.cfg.DailyQuotas[subject]; ok {
+		dailyLimit = n
+	}
+	monthlyLimit := l.cfg.MonthlyQuota
+	if n, ok := l.cfg.MonthlyQuotas[subject]; ok {
+		monthlyLimit = n
+	}
+
+	quotas := []Quota{
+		{Period: Day, Limit: int64(dailyLimit), ResetsAt: day.AddDate(0, 0, 1)},
+		{Period: Month, Limit: int64(monthlyLimit), ResetsAt: month.AddDate(0, 1, 0)},
+	}
+	counters := []counter{
+		{key: keyPrefix + "quota:" + subject + ":" + day.Format("2006-01-02")},
+		{key: keyPrefix + "quota:" + subject + ":" + month.Format("2006-01")},
+	}
+	for i := range counters {
+		counters[i].limit = quotas[i].Limit
+		counters[i].resetsAt = quotas[i].ResetsAt
+	}
+	return counters, quotas
+}
+
+func (q *Quota) setUsed(used int64) {
+	q.Used = used
+	if q.Limit > 0 {
+		remaining := max(q.Limit-used, 0)
+		q.Remaining = &remaining
+	}
+}
+
+// seconds converts a float number of seconds, never going below zero
+func seconds(s float64) time.Duration {
+	return time.Duration(max(s, 0) * float64(time.Second))
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills the bucket for the time since it was last used, then
+// takes a token if one is left. It reads the clock of the Redis server so
+// instances with skewed clocks share one view of the bucket, and it returns
+// the tokens as a string since Lua numbers come back truncated to integers.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+-- A bucket left alone long enough is full, the same as a missing one
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000))
+return {allowed, tostring(tokens)}
+`)
+
+// consumeScript adds ARGV[1] to every counter in KEYS unless one would go
+// over its limit. ARGV then holds a limit and an expiry, in Unix seconds, per
+// counter; a limit of 0 is unlimited. It returns the 1-based index of the
+// counter that would go over, or 0.
+var consumeScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+for i, key in ipairs(KEYS) do
+	local limit = tonumber(ARGV[i * 2])
+	local used = tonumber(redis.call('GET', key) or '0')
+	if limit > 0 and used + n > limit then
+		return i
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call('INCRBY', key, n)
+	redis.call('EXPIREAT', key, ARGV[i * 2 + 1])
+end
+return 0
+`)
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func (s *redisStore) take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	res, err := takeScript.Run(ctx, s.client, []string{key}, rate, burst).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res) != 2 {
+		return 0, false, fmt.Errorf("unexpected rate limit reply %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	raw, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("unexpected token count %q: %w", raw, err)
+	}
+	return tokens, allowed == 1, nil
+}
+
+func (s *redisStore) consume(ctx context.Context, counters []counter, n int64) (int, error) {
+	keys := make([]string, len(counters))
+	args := []any{n}
+	for i, c := range counters {
+		keys[i] = c.key
+		args = append(args, c.limit, expiry(c))
+	}
+	exceeded, err := consumeScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return 0, err
+	}
+	return exceeded - 1, nil
+}
+
+func (s *redisStore) refund(ctx context.Context, counters []counter, n int64) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, c := range counters {
+			pipe.DecrBy(ctx, c.key, n)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) counts(ctx context.Context, counters []counter) ([]int64, error) {
+	keys := make([]string, len(counters))
+	for i, c := range counters {
+		keys[i] = c.key
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		n, err := strconv.ParseInt(v.(string), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected quota count %v: %w", v, err)
+		}
+		counts[i] = max(n, 0)
+	}
+	return counts, nil
+}
+
+func (s *redisStore) ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func (s *redisStore) close() error {
+	return s.client.Close()
+}
+
+// expiry keeps a counter a day past the end of its period, so instances
+// whose clocks run behind still count into the same one
+func expiry(c counter) int64 {
+	return c.resetsAt.Add(24 * time.Hour).Unix()
+}
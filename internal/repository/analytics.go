@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"github.com/google/uuid"
+)
+
+// AnalyticsRepository runs the aggregate queries behind the analytics
+// endpoints. Time buckets are returned as bucket starts in the range's
+// timezone, ready for rng.Label.
+type AnalyticsRepository interface {
+	// FinalStatusCounts counts content created in the range by final status
+	FinalStatusCounts(ctx context.Context, rng timerange.Range) ([]LabelCount, error)
+	// FinalStatusOverTime counts content created in each bucket by final status
+	FinalStatusOverTime(ctx context.Context, rng timerange.Range) ([]BucketCount, error)
+	// ResultMediaTypeCounts counts moderation results created in the range by media type
+	ResultMediaTypeCounts(ctx context.Context, rng timerange.Range) ([]LabelCount, error)
+	// ResultStatusByMediaType counts moderation results created in the range by media type and status
+	ResultStatusByMediaType(ctx context.Context, rng timerange.Range) ([]MediaStatusCount, error)
+	// RiskHistogram counts matching results into the buckets between edges,
+	// returning one count per bucket. Buckets are [min, max) except the last,
+	// which includes its upper edge.
+	RiskHistogram(ctx context.Context, rng timerange.Range, edges []float64, filter ResultFilter) ([]int64, error)
+	// AuditsOverTime counts audits created in each bucket
+	AuditsOverTime(ctx context.Context, rng timerange.Range) ([]BucketCount, error)
+	Summary(ctx context.Context, rng timerange.Range) (Summary, error)
+	// AgreementPairs pairs each model verdict with the reviewer's verdict for
+	// the same modality on the content's latest audit, for audits in the range
+	AgreementPairs(ctx context.Context, rng timerange.Range, mediaTypes []string) ([]AgreementPair, error)
+	// StageEvents calls fn with the CREATED, MODERATED, AGGREGATED and REVIEWED
+	// events of content created in the range, grouped by content and in order
+	StageEvents(ctx context.Context, rng timerange.Range, fn func(StageEvent) error) error
+}
+
+type LabelCount struct {
+	Label string `json:"label"`
+	Value int64  `json:"value"`
+}
+
+// BucketCount is a count in one time bucket; Status is only set by queries grouping by it
+type BucketCount struct {
+	Bucket time.Time
+	Status string
+	Count  int64
+}
+
+type MediaStatusCount struct {
+	MediaType string
+	Status    string
+	Count     int64
+}
+
+// ResultFilter narrows moderation results; empty lists match everything
+type ResultFilter struct {
+	MediaTypes []string
+	Statuses   []string
+	Categories []string
+}
+
+// Summary covers the content, audits and results created in the range
+type Summary struct {
+	TotalContent  int64
+	PendingCount  int64
+	ApprovedCount int64
+	RejectedCount int64
+	FlaggedCount  int64
+	TotalAudits   int64
+	AvgRiskScore  float64
+}
+
+type AgreementPair struct {
+	Bucket        time.Time
+	Model         string
+	PolicyVersion string
+	MediaType     string
+	ModelStatus   string
+	HumanStatus   string
+	Count         int64
+}
+
+type StageEvent struct {
+	ContentID        uuid.UUID
+	ContentCreatedAt time.Time
+	EventType        models.EventType
+	MediaType        models.MediaType
+	Queue            string
+	CreatedAt        time.Time
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"github.com/google/uuid"
+)
+
+type analyticsRepository struct {
+	session
+}
+
+func (r analyticsRepository) FinalStatusCounts(ctx context.Context, rng timerange.Range) ([]repository.LabelCount, error) {
+	defer r.lock()()
+	byStatus := map[string]int64{}
+	for _, content := range r.data().contents {
+		if inRange(rng, content.CreatedAt) {
+			byStatus[string(content.FinalStatus)]++
+		}
+	}
+	return labelCounts(byStatus), nil
+}
+
+func (r analyticsRepository) FinalStatusOverTime(ctx context.Context, rng timerange.Range) ([]repository.BucketCount, error) {
+	defer r.lock()()
+	type key struct {
+		bucket time.Time
+		status string
+	}
+	byBucket := map[key]int64{}
+	for _, content := range r.data().contents {
+		if inRange(rng, content.CreatedAt) {
+			byBucket[key{rng.Truncate(content.CreatedAt), string(content.FinalStatus)}]++
+		}
+	}
+	counts := make([]repository.BucketCount, 0, len(byBucket))
+	for k, n := range byBucket {
+		counts = append(counts, repository.BucketCount{Bucket: k.bucket, Status: k.status, Count: n})
+	}
+	return counts, nil
+}
+
+func (r analyticsRepository) ResultMediaTypeCounts(ctx context.Context, rng timerange.Range) ([]repository.LabelCount, error) {
+	defer r.lock()()
+	byMediaType := map[string]int64{}
+	for _, result := range r.data().results {
+		if inRange(rng, result.CreatedAt) {
+			byMediaType[string(result.MediaType)]++
+		}
+	}
+	return labelCounts(byMediaType), nil
+}
+
+func (r analyticsRepository) ResultStatusByMediaType(ctx context.Context, rng timerange.Range) ([]repository.MediaStatusCount, error) {
+	defer r.lock()()
+	byKey := map[repository.MediaStatusCount]int64{}
+	for _, result := range r.data().results {
+		if inRange(rng, result.CreatedAt) {
+			byKey[repository.MediaStatusCount{MediaType: string(result.MediaType), Status: string(result.Status)}]++
+		}
+	}
+	counts := make([]repository.MediaStatusCount, 0, len(byKey))
+	for k, n := range byKey {
+		k.Count = n
+		counts = append(counts, k)
+	}
+	return counts, nil
+}
+
+func (r analyticsRepository) RiskHistogram(ctx context.Context, rng timerange.Range, edges []float64, filter repository.ResultFilter) ([]int64, error) {
+	defer r.lock()()
+	counts := make([]int64, len(edges)-1)
+	last := len(edges) - 2
+	for _, result := range r.data().results {
+		if !inRange(rng, result.CreatedAt) ||
+			(len(filter.MediaTypes) > 0 && !slices.Contains(filter.MediaTypes, string(result.MediaType))) ||
+			(len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, string(result.Status))) ||
+			(len(filter.Categories) > 0 && !slices.Contains(filter.Categories, string(result.Category))) {
+			continue
+		}
+		for i := 0; i <= last; i++ {
+			score := result.RiskScore
+			if score >= edges[i] && (score < edges[i+1] || (i == last && score == edges[i+1])) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r analyticsRepository) AuditsOverTime(ctx context.Context, rng timerange.Range) ([]repository.BucketCount, error) {
+	defer r.lock()()
+	byBucket := map[time.Time]int64{}
+	for _, audit := range r.data().audits {
+		if inRange(rng, audit.CreatedAt) {
+			byBucket[rng.Truncate(audit.CreatedAt)]++
+		}
+	}
+	counts := make([]repository.BucketCount, 0, len(byBucket))
+	for bucket, n := range byBucket {
+		counts = append(counts, repository.BucketCount{Bucket: bucket, Count: n})
+	}
+	return counts, nil
+}
+
+func (r analyticsRepository) Summary(ctx context.Context, rng timerange.Range) (repository.Summary, error) {
+	defer r.lock()()
+	var summary repository.Summary
+	for _, content := range r.data().contents {
+		if !inRange(rng, content.CreatedAt) {
+			continue
+		}
+		summary.TotalContent++
+		switch content.FinalStatus {
+		case models.Pending:
+			summary.PendingCount++
+		case models.Approved:
+			summary.ApprovedCount++
+		case models.Rejected:
+			summary.RejectedCount++
+		case models.Flagged:
+			summary.FlaggedCount++
+		}
+	}
+	for _, audit := range r.data().audits {
+		if inRange(rng, audit.CreatedAt) {
+			summary.TotalAudits++
+		}
+	}
+
+	total, results := 0.0, 0
+	for _, result := range r.data().results {
+		if inRange(rng, result.CreatedAt) {
+			total += result.RiskScore
+			results++
+		}
+	}
+	if results > 0 {
+		summary.AvgRiskScore = total / float64(results)
+	}
+	return summary, nil
+}
+
+func (r analyticsRepository) AgreementPairs(ctx context.Context, rng timerange.Range, mediaTypes []string) ([]repository.AgreementPair, error) {
+	defer r.lock()()
+
+	latest := map[uuid.UUID]models.Audit{}
+	for _, audit := range r.data().audits {
+		if current, ok := latest[audit.ContentId]; !ok || !audit.CreatedAt.Before(current.CreatedAt) {
+			latest[audit.ContentId] = audit
+		}
+	}
+
+	byKey := map[repository.AgreementPair]int64{}
+	for _, result := range r.data().results {
+		audit, ok := latest[result.ContentId]
+		if !ok || !inRange(rng, audit.CreatedAt) {
+			continue
+		}
+		if len(mediaTypes) > 0 && !slices.Contains(mediaTypes, string(result.MediaType)) {
+			continue
+		}
+		var human models.ContentStatus
+		switch result.MediaType {
+		case models.Txt:
+			human = audit.TextStatus
+		case models.Img:
+			human = audit.ImageStatus
+		case models.Vid:
+			human = audit.VideoStatus
+		}
+		if human == "" {
+			continue
+		}
+		byKey[repository.AgreementPair{
+			Bucket:        rng.Truncate(audit.CreatedAt),
+			Model:         result.Model,
+			PolicyVersion: result.PolicyVersion,
+			MediaType:     string(result.MediaType),
+			ModelStatus:   string(result.Status),
+			HumanStatus:   string(human),
+		}]++
+	}
+
+	pairs := make([]repository.AgreementPair, 0, len(byKey))
+	for k, n := range byKey {
+		k.Count = n
+		pairs = append(pairs, k)
+	}
+	return pairs, nil
+}
+
+func (r analyticsRepository) StageEvents(ctx context.Context, rng timerange.Range, fn func(repository.StageEvent) error) error {
+	// Collect under the lock, but call fn without it so it may use the repositories
+	events := func() []repository.StageEvent {
+		defer r.lock()()
+		stages := []models.EventType{models.Created, models.Moderated, models.Aggregated, models.HumanReviewed}
+		var events []repository.StageEvent
+		for _, event := range r.data().events {
+			content, ok := r.data().contents[event.ContentId]
+			if !ok || !inRange(rng, content.CreatedAt) || !slices.Contains(stages, event.EventType) {
+				continue
+			}
+			events = append(events, repository.StageEvent{
+				ContentID:        event.ContentId,
+				ContentCreatedAt: content.CreatedAt,
+				EventType:        event.EventType,
+				MediaType:        event.MediaType,
+				Queue:            event.Queue,
+				CreatedAt:        event.CreatedAt,
+			})
+		}
+		return events
+	}()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if n := bytes.Compare(events[i].ContentID[:], events[j].ContentID[:]); n != 0 {
+			return n < 0
+		}
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inRange mirrors timerange.Range.Where
+func inRange(rng timerange.Range, t time.Time) bool {
+	if !rng.From.IsZero() && t.Before(rng.From) {
+		return false
+	}
+	if !rng.To.IsZero() && !t.Before(rng.To) {
+		return false
+	}
+	return true
+}
+
+func labelCounts(byLabel map[string]int64) []repository.LabelCount {
+	counts := make([]repository.LabelCount, 0, len(byLabel))
+	for label, n := range byLabel {
+		counts = append(counts, repository.LabelCount{Label: label, Value: n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Label < counts[j].Label })
+	return counts
+}
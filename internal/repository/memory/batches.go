@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type batchRepository struct {
+	session
+}
+
+func (r batchRepository) Create(ctx context.Context, batch *models.Batch) error {
+	defer r.lock()()
+	batch.ID = newID(batch.ID)
+	stamp(&batch.CreatedAt, time.Now())
+	r.data().batches[batch.ID] = *batch
+	return nil
+}
+
+func (r batchRepository) Get(ctx context.Context, id uuid.UUID, tenantID string) (models.Batch, error) {
+	defer r.lock()()
+	batch, ok := r.data().batches[id]
+	if !ok || (tenantID != "" && batch.TenantID != tenantID) {
+		return models.Batch{}, repository.ErrNotFound
+	}
+	return batch, nil
+}
+
+func (r batchRepository) Save(ctx context.Context, batch *models.Batch) error {
+	defer r.lock()()
+	r.data().batches[batch.ID] = *batch
+	return nil
+}
+
+func (r batchRepository) StatusCounts(ctx context.Context, id uuid.UUID) ([]repository.LabelCount, error) {
+	defer r.lock()()
+	byStatus := map[string]int64{}
+	for _, content := range r.data().contents {
+		if content.BatchID != nil && *content.BatchID == id {
+			byStatus[string(content.FinalStatus)]++
+		}
+	}
+	return labelCounts(byStatus), nil
+}
@@ -0,0 +1,387 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type contentRepository struct {
+	session
+}
+
+func (r contentRepository) Create(ctx context.Context, content *models.Content) error {
+	defer r.lock()()
+	r.create(content, time.Now())
+	return nil
+}
+
+func (r contentRepository) CreateMany(ctx context.Context, contents []models.Content) error {
+	defer r.lock()()
+	now := time.Now()
+	for i := range contents {
+		r.create(&contents[i], now)
+	}
+	return nil
+}
+
+func (r contentRepository) create(content *models.Content, now time.Time) {
+	content.ID = newID(content.ID)
+	stamp(&content.CreatedAt, now)
+	stamp(&content.UpdatedAt, now)
+	r.data().contents[content.ID] = bare(*content)
+}
+
+func (r contentRepository) Get(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	defer r.lock()()
+	content, ok := r.data().contents[id]
+	if !ok {
+		return content, repository.ErrNotFound
+	}
+	return content, nil
+}
+
+func (r contentRepository) GetDetailed(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	defer r.lock()()
+	content, ok := r.data().contents[id]
+	if !ok {
+		return content, repository.ErrNotFound
+	}
+	return r.detailed(content), nil
+}
+
+func (r contentRepository) GetMany(ctx context.Context, ids []uuid.UUID) ([]models.Content, error) {
+	defer r.lock()()
+	var contents []models.Content
+	for _, id := range ids {
+		if content, ok := r.data().contents[id]; ok {
+			contents = append(contents, content)
+		}
+	}
+	return contents, nil
+}
+
+// Lock is Get, since a transaction already holds the whole store
+func (r contentRepository) Lock(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	return r.Get(ctx, id)
+}
+
+func (r contentRepository) Save(ctx context.Context, content *models.Content) error {
+	defer r.lock()()
+	if content.ID == uuid.Nil {
+		r.create(content, time.Now())
+		return nil
+	}
+	content.UpdatedAt = time.Now()
+	r.data().contents[content.ID] = bare(*content)
+	return nil
+}
+
+// DeleteMany also removes the child records, like the foreign keys do
+func (r contentRepository) DeleteMany(ctx context.Context, ids []uuid.UUID) error {
+	defer r.lock()()
+	d := r.data()
+	for _, id := range ids {
+		delete(d.contents, id)
+	}
+	d.results = slices.DeleteFunc(d.results, func(result models.ModerationResult) bool {
+		return slices.Contains(ids, result.ContentId)
+	})
+	d.events = slices.DeleteFunc(d.events, func(event models.ModerationEvents) bool {
+		return slices.Contains(ids, event.ContentId)
+	})
+	d.audits = slices.DeleteFunc(d.audits, func(audit models.Audit) bool {
+		return slices.Contains(ids, audit.ContentId)
+	})
+	return nil
+}
+
+func (r contentRepository) List(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error) {
+	defer r.lock()()
+	return r.page(r.matching(filter), page), nil
+}
+
+func (r contentRepository) ListDetailed(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error) {
+	defer r.lock()()
+	contents := r.page(r.matching(filter), page)
+	for i := range contents {
+		contents[i] = r.detailed(contents[i])
+	}
+	return contents, nil
+}
+
+func (r contentRepository) Count(ctx context.Context, filter listing.ContentFilter) (int64, error) {
+	defer r.lock()()
+	return int64(len(r.matching(filter))), nil
+}
+
+// Search approximates the Postgres full-text search: every term has to
+// appear as a case-insensitive substring, terms prefixed with - must not,
+// and the rank counts the occurrences
+func (r contentRepository) Search(ctx context.Context, query repository.SearchQuery) ([]repository.SearchMatch, error) {
+	defer r.lock()()
+
+	terms := parseSearch(query.Q)
+
+	type ranked struct {
+		content models.Content
+		match   repository.SearchMatch
+	}
+	var hits []ranked
+	for _, content := range r.matching(query.Filter) {
+		match := repository.SearchMatch{ContentID: content.ID}
+		textRank, textMatched := terms.rank(content.Text)
+		if textMatched {
+			match.TextHighlight = terms.highlight(content.Text)
+			if query.In != repository.SearchExplanation {
+				match.Rank += textRank
+			}
+		}
+
+		explanationMatched := false
+		if query.In != repository.SearchText {
+			best := 0.0
+			for _, result := range r.data().results {
+				if result.ContentId != content.ID {
+					continue
+				}
+				if rank, ok := terms.rank(result.Explaination); ok {
+					explanationMatched = true
+					best = max(best, rank)
+				}
+			}
+			match.Rank += best
+		}
+
+		switch {
+		case query.In == repository.SearchText && !textMatched,
+			query.In == repository.SearchExplanation && !explanationMatched,
+			!textMatched && !explanationMatched:
+			continue
+		}
+		hits = append(hits, ranked{content, match})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].match.Rank != hits[j].match.Rank {
+			return hits[i].match.Rank > hits[j].match.Rank
+		}
+		return hits[i].content.CreatedAt.After(hits[j].content.CreatedAt)
+	})
+
+	if query.Offset >= len(hits) {
+		return []repository.SearchMatch{}, nil
+	}
+	hits = hits[query.Offset:]
+	if len(hits) > query.Limit+1 {
+		hits = hits[:query.Limit+1]
+	}
+
+	matches := make([]repository.SearchMatch, len(hits))
+	for i, hit := range hits {
+		matches[i] = hit.match
+	}
+	return matches, nil
+}
+
+// matching returns the content passing the filter, with the store lock held
+func (r contentRepository) matching(filter listing.ContentFilter) []models.Content {
+	var contents []models.Content
+	for _, content := range r.data().contents {
+		if r.matches(filter, content) {
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
+
+// matches mirrors listing.ContentFilter.Apply
+func (r contentRepository) matches(f listing.ContentFilter, c models.Content) bool {
+	if f.TenantID != "" && c.TenantID != f.TenantID {
+		return false
+	}
+	if f.AuthorID != "" && c.AuthorID != f.AuthorID {
+		return false
+	}
+	if len(f.FinalStatus) > 0 && !slices.Contains(f.FinalStatus, c.FinalStatus) {
+		return false
+	}
+	if len(f.TextStatus) > 0 && !slices.Contains(f.TextStatus, c.TextStatus) {
+		return false
+	}
+	if len(f.ImageStatus) > 0 && !slices.Contains(f.ImageStatus, c.ImageStatus) {
+		return false
+	}
+	if len(f.VideoStatus) > 0 && !slices.Contains(f.VideoStatus, c.VideoStatus) {
+		return false
+	}
+	if f.HasImage != nil && *f.HasImage != (c.Image != "") {
+		return false
+	}
+	if f.HasVideo != nil && *f.HasVideo != (c.Video != "") {
+		return false
+	}
+	if f.CreatedFrom != nil && c.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedBefore != nil && !c.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	if f.CreatedTo != nil && c.CreatedAt.After(*f.CreatedTo) {
+		return false
+	}
+	if f.MinRisk != nil || f.MaxRisk != nil {
+		minRisk, maxRisk := 0.0, 1.0
+		if f.MinRisk != nil {
+			minRisk = *f.MinRisk
+		}
+		if f.MaxRisk != nil {
+			maxRisk = *f.MaxRisk
+		}
+		found := false
+		for _, result := range r.data().results {
+			if result.ContentId == c.ID && result.RiskScore >= minRisk && result.RiskScore <= maxRisk {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// page mirrors listing.Page.Apply: keyset condition, ordering and one extra item
+func (r contentRepository) page(contents []models.Content, p listing.Page) []models.Content {
+	value := func(c models.Content) time.Time {
+		if p.Sort == "updatedAt" {
+			return c.UpdatedAt
+		}
+		return c.CreatedAt
+	}
+	// compare orders by the sort column, then by ID like Postgres compares UUIDs
+	compare := func(v time.Time, id uuid.UUID, c models.Content) int {
+		if n := v.Compare(value(c)); n != 0 {
+			return n
+		}
+		return bytes.Compare(id[:], c.ID[:])
+	}
+
+	if p.Cursor != nil {
+		contents = slices.DeleteFunc(contents, func(c models.Content) bool {
+			n := compare(value(c), c.ID, models.Content{CreatedAt: p.Cursor.Value, UpdatedAt: p.Cursor.Value, ID: p.Cursor.ID})
+			if p.Desc {
+				return n >= 0
+			}
+			return n <= 0
+		})
+	}
+
+	sort.Slice(contents, func(i, j int) bool {
+		n := compare(value(contents[i]), contents[i].ID, contents[j])
+		if p.Desc {
+			return n > 0
+		}
+		return n < 0
+	})
+
+	if len(contents) > p.Limit+1 {
+		contents = contents[:p.Limit+1]
+	}
+	return contents
+}
+
+// detailed attaches the content's results, events and audits, with the store lock held
+func (r contentRepository) detailed(c models.Content) models.Content {
+	for _, result := range r.data().results {
+		if result.ContentId == c.ID {
+			c.ModerationResult = append(c.ModerationResult, result)
+		}
+	}
+	for _, event := range r.data().events {
+		if event.ContentId == c.ID {
+			c.ModerationEvents = append(c.ModerationEvents, event)
+		}
+	}
+	for _, audit := range r.data().audits {
+		if audit.ContentId == c.ID {
+			c.Audit = append(c.Audit, audit)
+		}
+	}
+	return c
+}
+
+// bare drops loaded associations so they are never stored on the content
+func bare(c models.Content) models.Content {
+	c.ModerationResult = nil
+	c.ModerationEvents = nil
+	c.Audit = nil
+	return c
+}
+
+// searchTerms is a parsed search query
+type searchTerms struct {
+	include []string
+	exclude []string
+}
+
+var searchWord = regexp.MustCompile(`-?[\p{L}\p{N}_']+`)
+
+func parseSearch(q string) searchTerms {
+	var terms searchTerms
+	for _, word := range searchWord.FindAllString(strings.ToLower(q), -1) {
+		switch {
+		case word == "or":
+		case strings.HasPrefix(word, "-"):
+			terms.exclude = append(terms.exclude, word[1:])
+		default:
+			terms.include = append(terms.include, word)
+		}
+	}
+	return terms
+}
+
+// rank counts the occurrences of the included terms in text, and reports
+// whether text matches at all
+func (t searchTerms) rank(text string) (float64, bool) {
+	if len(t.include) == 0 {
+		return 0, false
+	}
+	lower := strings.ToLower(text)
+	for _, term := range t.exclude {
+		if strings.Contains(lower, term) {
+			return 0, false
+		}
+	}
+	n := 0
+	for _, term := range t.include {
+		count := strings.Count(lower, term)
+		if count == 0 {
+			return 0, false
+		}
+		n += count
+	}
+	return float64(n), true
+}
+
+// highlight escapes text and wraps every occurrence of the included terms in
+// <mark> tags
+func (t searchTerms) highlight(text string) string {
+	quoted := make([]string, len(t.include))
+	for i, term := range t.include {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	marked := re.ReplaceAllString(text, repository.HighlightStart+"$0"+repository.HighlightStop)
+	return repository.EscapeHighlight(marked)
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type exportRepository struct {
+	session
+}
+
+func (r exportRepository) Create(ctx context.Context, export *models.Export) error {
+	defer r.lock()()
+	export.ID = newID(export.ID)
+	stamp(&export.CreatedAt, time.Now())
+	r.data().exports = append(r.data().exports, *export)
+	return nil
+}
+
+func (r exportRepository) Get(ctx context.Context, id uuid.UUID) (models.Export, error) {
+	defer r.lock()()
+	for _, export := range r.data().exports {
+		if export.ID == id {
+			return export, nil
+		}
+	}
+	return models.Export{}, repository.ErrNotFound
+}
+
+func (r exportRepository) List(ctx context.Context, tenantID string, limit int) ([]models.Export, error) {
+	defer r.lock()()
+	var exports []models.Export
+	// Exports are stored oldest first
+	for i := len(r.data().exports) - 1; i >= 0 && len(exports) < limit; i-- {
+		if export := r.data().exports[i]; tenantID == "" || export.TenantID == tenantID {
+			exports = append(exports, export)
+		}
+	}
+	return exports, nil
+}
+
+func (r exportRepository) Save(ctx context.Context, export *models.Export) error {
+	defer r.lock()()
+	for i := range r.data().exports {
+		if r.data().exports[i].ID == export.ID {
+			r.data().exports[i] = *export
+			return nil
+		}
+	}
+	export.ID = newID(export.ID)
+	stamp(&export.CreatedAt, time.Now())
+	r.data().exports = append(r.data().exports, *export)
+	return nil
+}
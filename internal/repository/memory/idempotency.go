@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+)
+
+type idempotencyRepository struct {
+	session
+}
+
+// idempotencyID identifies a key within its tenant
+type idempotencyID struct {
+	tenantID string
+	key      string
+}
+
+func (r idempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	defer r.lock()()
+	now := time.Now()
+	id := idempotencyID{key.TenantID, key.Key}
+	if held, ok := r.data().idempotency[id]; ok && held.ExpiresAt.After(now) {
+		return held, false, nil
+	}
+	stamp(&key.CreatedAt, now)
+	r.data().idempotency[id] = *key
+	return models.IdempotencyKey{}, true, nil
+}
+
+func (r idempotencyRepository) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	defer r.lock()()
+	id := idempotencyID{key.TenantID, key.Key}
+	held, ok := r.data().idempotency[id]
+	if !ok {
+		return nil
+	}
+	held.StatusCode = key.StatusCode
+	held.ContentType = key.ContentType
+	held.ResponseBody = key.ResponseBody
+	held.ExpiresAt = key.ExpiresAt
+	r.data().idempotency[id] = held
+	return nil
+}
+
+func (r idempotencyRepository) Release(ctx context.Context, tenantID, key string) error {
+	defer r.lock()()
+	id := idempotencyID{tenantID, key}
+	if held, ok := r.data().idempotency[id]; ok && held.StatusCode == 0 {
+		delete(r.data().idempotency, id)
+	}
+	return nil
+}
+
+func (r idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	defer r.lock()()
+	var deleted int64
+	for id, held := range r.data().idempotency {
+		if !held.ExpiresAt.After(now) {
+			delete(r.data().idempotency, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,145 @@
+// Package memory implements the repositories with plain Go data structures,
+// so handlers and workers can be exercised in-process without a database.
+// Transactions hold a store-wide lock and roll back by restoring a snapshot.
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type store struct {
+	mu   sync.Mutex
+	data *data
+}
+
+// data is everything the store holds. Child records are kept in insertion order.
+type data struct {
+	contents      map[uuid.UUID]models.Content
+	results       []models.ModerationResult
+	events        []models.ModerationEvents
+	audits        []models.Audit
+	batches       map[uuid.UUID]models.Batch
+	blocklist     []models.BlocklistEntry
+	policies      []models.Policy
+	subscriptions []models.WebhookSubscription
+	deliveries    []models.WebhookDelivery
+	attempts      []models.WebhookAttempt
+	exports       []models.Export
+	idempotency   map[idempotencyID]models.IdempotencyKey
+	modelCalls    []models.ModelCall
+	usageDays     []models.ModelUsageDay
+	budgetAlerts  []models.BudgetAlert
+}
+
+func (d *data) clone() *data {
+	return &data{
+		contents:      maps.Clone(d.contents),
+		results:       slices.Clone(d.results),
+		events:        slices.Clone(d.events),
+		audits:        slices.Clone(d.audits),
+		batches:       maps.Clone(d.batches),
+		blocklist:     slices.Clone(d.blocklist),
+		policies:      slices.Clone(d.policies),
+		subscriptions: slices.Clone(d.subscriptions),
+		deliveries:    slices.Clone(d.deliveries),
+		attempts:      slices.Clone(d.attempts),
+		exports:       slices.Clone(d.exports),
+		idempotency:   maps.Clone(d.idempotency),
+		modelCalls:    slices.Clone(d.modelCalls),
+		usageDays:     slices.Clone(d.usageDays),
+		budgetAlerts:  slices.Clone(d.budgetAlerts),
+	}
+}
+
+// New returns empty repositories sharing one in-memory store
+func New() *repository.Repositories {
+	s := &store{data: &data{
+		contents:    map[uuid.UUID]models.Content{},
+		batches:     map[uuid.UUID]models.Batch{},
+		idempotency: map[idempotencyID]models.IdempotencyKey{},
+	}}
+	return newRepositories(session{store: s})
+}
+
+// session is how every repository reaches the store. Inside a transaction
+// the store lock is already held, so repository calls must not take it again.
+type session struct {
+	store *store
+	inTx  bool
+}
+
+// lock takes the store lock unless the session's transaction holds it; use as defer s.lock()()
+func (s session) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.store.mu.Lock()
+	return s.store.mu.Unlock
+}
+
+func (s session) data() *data {
+	return s.store.data
+}
+
+func newRepositories(s session) *repository.Repositories {
+	return &repository.Repositories{
+		Contents:    contentRepository{s},
+		Results:     resultRepository{s},
+		Events:      eventRepository{s},
+		Audits:      auditRepository{s},
+		Analytics:   analyticsRepository{s},
+		Batches:     batchRepository{s},
+		Blocklist:   blocklistRepository{s},
+		Policies:    policyRepository{s},
+		Webhooks:    webhookRepository{s},
+		Exports:     exportRepository{s},
+		Idempotency: idempotencyRepository{s},
+		Usage:       usageRepository{s},
+		Transactor:  transactor{s},
+	}
+}
+
+type transactor struct {
+	session
+}
+
+// Transaction runs fn under the store lock. A transaction started inside
+// another one joins it, so an error rolls back the outermost transaction.
+func (t transactor) Transaction(ctx context.Context, fn func(tx *repository.Repositories) error) error {
+	if t.inTx {
+		return fn(newRepositories(t.session))
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	snapshot := t.store.data.clone()
+	if err := fn(newRepositories(session{store: t.store, inTx: true})); err != nil {
+		t.store.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// newID returns id, or a fresh one when it is unset, like the database default
+func newID(id uuid.UUID) uuid.UUID {
+	if id == uuid.Nil {
+		return uuid.New()
+	}
+	return id
+}
+
+// stamp fills in an unset creation time the way GORM does
+func stamp(createdAt *time.Time, now time.Time) {
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type blocklistRepository struct {
+	session
+}
+
+func (r blocklistRepository) List(ctx context.Context, tenantID string) ([]models.BlocklistEntry, error) {
+	defer r.lock()()
+	var entries []models.BlocklistEntry
+	for _, entry := range r.data().blocklist {
+		if tenantID == "" || entry.TenantID == tenantID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].TenantID != entries[j].TenantID {
+			return entries[i].TenantID < entries[j].TenantID
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (r blocklistRepository) Create(ctx context.Context, entry *models.BlocklistEntry) error {
+	defer r.lock()()
+	for _, existing := range r.data().blocklist {
+		if existing.TenantID == entry.TenantID && existing.Term == entry.Term {
+			return fmt.Errorf("blocklist already has %q for tenant %q", entry.Term, entry.TenantID)
+		}
+	}
+	entry.ID = newID(entry.ID)
+	stamp(&entry.CreatedAt, time.Now())
+	r.data().blocklist = append(r.data().blocklist, *entry)
+	return nil
+}
+
+func (r blocklistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+	for i, entry := range r.data().blocklist {
+		if entry.ID == id {
+			r.data().blocklist = append(r.data().blocklist[:i:i], r.data().blocklist[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+type policyRepository struct {
+	session
+}
+
+func (r policyRepository) List(ctx context.Context, tenantID string) ([]models.Policy, error) {
+	defer r.lock()()
+	var policies []models.Policy
+	for _, policy := range r.data().policies {
+		if tenantID == "" || policy.TenantID == tenantID {
+			policies = append(policies, policy)
+		}
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		a, b := policies[i], policies[j]
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.MediaType != b.MediaType {
+			return a.MediaType < b.MediaType
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return policies, nil
+}
+
+func (r policyRepository) Get(ctx context.Context, id uuid.UUID) (models.Policy, error) {
+	defer r.lock()()
+	if i := r.index(id); i >= 0 {
+		return r.data().policies[i], nil
+	}
+	return models.Policy{}, repository.ErrNotFound
+}
+
+func (r policyRepository) Active(ctx context.Context, tenantID string, mediaType models.MediaType) (*models.Policy, error) {
+	defer r.lock()()
+	for _, policy := range r.data().policies {
+		if policy.TenantID == tenantID && policy.MediaType == mediaType && policy.Active {
+			return &policy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r policyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	defer r.lock()()
+	for _, existing := range r.data().policies {
+		if existing.TenantID == policy.TenantID && existing.MediaType == policy.MediaType && existing.Version == policy.Version {
+			return fmt.Errorf("policy %s %s already exists for tenant %q", policy.MediaType, policy.Version, policy.TenantID)
+		}
+		if policy.Active && existing.Active && existing.TenantID == policy.TenantID && existing.MediaType == policy.MediaType {
+			return fmt.Errorf("tenant %q already has an active %s policy", policy.TenantID, policy.MediaType)
+		}
+	}
+	policy.ID = newID(policy.ID)
+	stamp(&policy.CreatedAt, time.Now())
+	r.data().policies = append(r.data().policies, *policy)
+	return nil
+}
+
+func (r policyRepository) Activate(ctx context.Context, id uuid.UUID) (models.Policy, error) {
+	defer r.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return models.Policy{}, repository.ErrNotFound
+	}
+	policies := r.data().policies
+	for j := range policies {
+		if policies[j].TenantID == policies[i].TenantID && policies[j].MediaType == policies[i].MediaType {
+			policies[j].Active = j == i
+		}
+	}
+	return policies[i], nil
+}
+
+func (r policyRepository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return repository.ErrNotFound
+	}
+	r.data().policies[i].Active = false
+	return nil
+}
+
+// index finds the policy by ID, or returns -1, with the store lock held
+func (r policyRepository) index(id uuid.UUID) int {
+	for i, policy := range r.data().policies {
+		if policy.ID == id {
+			return i
+		}
+	}
+	return -1
+}
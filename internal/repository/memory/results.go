@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type resultRepository struct {
+	session
+}
+
+func (r resultRepository) Create(ctx context.Context, result *models.ModerationResult) error {
+	defer r.lock()()
+	result.ID = newID(result.ID)
+	stamp(&result.CreatedAt, time.Now())
+	stored := *result
+	stored.Content = models.Content{}
+	r.data().results = append(r.data().results, stored)
+	return nil
+}
+
+func (r resultRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationResult, error) {
+	defer r.lock()()
+	var results []models.ModerationResult
+	for _, result := range r.data().results {
+		if result.ContentId == contentID {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func (r resultRepository) SearchExplanations(ctx context.Context, contentIDs []uuid.UUID, q string) ([]repository.ExplanationMatch, error) {
+	defer r.lock()()
+	terms := parseSearch(q)
+	var matches []repository.ExplanationMatch
+	for _, result := range r.data().results {
+		if !slices.Contains(contentIDs, result.ContentId) {
+			continue
+		}
+		if _, ok := terms.rank(result.Explaination); !ok {
+			continue
+		}
+		matches = append(matches, repository.ExplanationMatch{
+			ResultID:  result.ID,
+			ContentID: result.ContentId,
+			MediaType: result.MediaType,
+			Status:    result.Status,
+			Highlight: terms.highlight(result.Explaination),
+		})
+	}
+	return matches, nil
+}
+
+type eventRepository struct {
+	session
+}
+
+func (r eventRepository) Create(ctx context.Context, event *models.ModerationEvents) error {
+	defer r.lock()()
+	r.create(event, time.Now())
+	return nil
+}
+
+func (r eventRepository) CreateMany(ctx context.Context, events []models.ModerationEvents) error {
+	defer r.lock()()
+	now := time.Now()
+	for i := range events {
+		r.create(&events[i], now)
+	}
+	return nil
+}
+
+func (r eventRepository) create(event *models.ModerationEvents, now time.Time) {
+	event.ID = newID(event.ID)
+	stamp(&event.CreatedAt, now)
+	stored := *event
+	stored.Content = models.Content{}
+	r.data().events = append(r.data().events, stored)
+}
+
+func (r eventRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationEvents, error) {
+	defer r.lock()()
+	var events []models.ModerationEvents
+	for _, event := range r.data().events {
+		if event.ContentId == contentID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type auditRepository struct {
+	session
+}
+
+func (r auditRepository) Create(ctx context.Context, audit *models.Audit) error {
+	defer r.lock()()
+	audit.ID = newID(audit.ID)
+	stamp(&audit.CreatedAt, time.Now())
+	stored := *audit
+	stored.Content = models.Content{}
+	r.data().audits = append(r.data().audits, stored)
+	return nil
+}
+
+func (r auditRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.Audit, error) {
+	defer r.lock()()
+	var audits []models.Audit
+	for _, audit := range r.data().audits {
+		if audit.ContentId == contentID {
+			audits = append(audits, audit)
+		}
+	}
+	return audits, nil
+}
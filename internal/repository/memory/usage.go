@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+)
+
+type usageRepository struct {
+	session
+}
+
+func (r usageRepository) Record(ctx context.Context, call *models.ModelCall) error {
+	defer r.lock()()
+	call.ID = newID(call.ID)
+	stamp(&call.CreatedAt, time.Now())
+	r.data().modelCalls = append(r.data().modelCalls, *call)
+
+	created := call.CreatedAt.UTC()
+	day := time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC)
+	days := r.data().usageDays
+	i := slices.IndexFunc(days, func(d models.ModelUsageDay) bool {
+		return d.TenantID == call.TenantID && d.Day.Equal(day) && d.Model == call.Model
+	})
+	if i < 0 {
+		days = append(days, models.ModelUsageDay{TenantID: call.TenantID, Day: day, Model: call.Model})
+		i = len(days) - 1
+	}
+	days[i].Calls++
+	days[i].InputTokens += call.InputTokens
+	days[i].OutputTokens += call.OutputTokens
+	days[i].Cost += call.Cost
+	r.data().usageDays = days
+	return nil
+}
+
+func (r usageRepository) Days(ctx context.Context, tenantID string, from, to time.Time) ([]models.ModelUsageDay, error) {
+	defer r.lock()()
+	var days []models.ModelUsageDay
+	for _, d := range r.data().usageDays {
+		if d.TenantID == tenantID && !d.Day.Before(from) && d.Day.Before(to) {
+			days = append(days, d)
+		}
+	}
+	slices.SortFunc(days, func(a, b models.ModelUsageDay) int {
+		return cmp.Or(a.Day.Compare(b.Day), cmp.Compare(a.Model, b.Model))
+	})
+	return days, nil
+}
+
+func (r usageRepository) Cost(ctx context.Context, tenantID string, from, to time.Time) (float64, error) {
+	days, err := r.Days(ctx, tenantID, from, to)
+	var cost float64
+	for _, d := range days {
+		cost += d.Cost
+	}
+	return cost, err
+}
+
+func (r usageRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModelCall, error) {
+	defer r.lock()()
+	var calls []models.ModelCall
+	for _, call := range r.data().modelCalls {
+		if call.ContentID == contentID {
+			calls = append(calls, call)
+		}
+	}
+	return calls, nil
+}
+
+func (r usageRepository) CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	defer r.lock()()
+	for _, held := range r.data().budgetAlerts {
+		if held.TenantID == alert.TenantID && held.Month == alert.Month {
+			return false, nil
+		}
+	}
+	stamp(&alert.CreatedAt, time.Now())
+	r.data().budgetAlerts = append(r.data().budgetAlerts, *alert)
+	return true, nil
+}
+
+func (r usageRepository) ListAlerts(ctx context.Context, tenantID string) ([]models.BudgetAlert, error) {
+	defer r.lock()()
+	var alerts []models.BudgetAlert
+	for _, alert := range r.data().budgetAlerts {
+		if alert.TenantID == tenantID {
+			alerts = append(alerts, alert)
+		}
+	}
+	slices.SortFunc(alerts, func(a, b models.BudgetAlert) int {
+		return cmp.Compare(b.Month, a.Month)
+	})
+	return alerts, nil
+}
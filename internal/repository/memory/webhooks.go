@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+)
+
+type webhookRepository struct {
+	session
+}
+
+func (r webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	defer r.lock()()
+	now := time.Now()
+	subscription.ID = newID(subscription.ID)
+	stamp(&subscription.CreatedAt, now)
+	stamp(&subscription.UpdatedAt, now)
+	r.data().subscriptions = append(r.data().subscriptions, *subscription)
+	return nil
+}
+
+func (r webhookRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error) {
+	defer r.lock()()
+	var subscriptions []models.WebhookSubscription
+	for _, subscription := range r.data().subscriptions {
+		if tenantID == "" || subscription.TenantID == tenantID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r webhookRepository) ActiveSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error) {
+	defer r.lock()()
+	var subscriptions []models.WebhookSubscription
+	for _, subscription := range r.data().subscriptions {
+		if subscription.Active && (tenantID == "" || subscription.TenantID == tenantID) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID, tenantID string) (models.WebhookSubscription, error) {
+	defer r.lock()()
+	for _, subscription := range r.data().subscriptions {
+		if subscription.ID == id && (tenantID == "" || subscription.TenantID == tenantID) {
+			return subscription, nil
+		}
+	}
+	return models.WebhookSubscription{}, repository.ErrNotFound
+}
+
+func (r webhookRepository) DeactivateSubscription(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+	for i := range r.data().subscriptions {
+		if r.data().subscriptions[i].ID == id {
+			r.data().subscriptions[i].Active = false
+			r.data().subscriptions[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	defer r.lock()()
+	now := time.Now()
+	delivery.ID = newID(delivery.ID)
+	stamp(&delivery.CreatedAt, now)
+	stamp(&delivery.UpdatedAt, now)
+	r.data().deliveries = append(r.data().deliveries, bareDelivery(*delivery))
+	return nil
+}
+
+func (r webhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	defer r.lock()()
+	for _, delivery := range r.data().deliveries {
+		if delivery.ID != id {
+			continue
+		}
+		for _, subscription := range r.data().subscriptions {
+			if subscription.ID == delivery.SubscriptionID {
+				delivery.Subscription = subscription
+			}
+		}
+		for _, attempt := range r.data().attempts {
+			if attempt.DeliveryID == delivery.ID {
+				delivery.WebhookAttempt = append(delivery.WebhookAttempt, attempt)
+			}
+		}
+		return delivery, nil
+	}
+	return models.WebhookDelivery{}, repository.ErrNotFound
+}
+
+func (r webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	defer r.lock()()
+	var deliveries []models.WebhookDelivery
+	// Deliveries are stored oldest first
+	for i := len(r.data().deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if delivery := r.data().deliveries[i]; delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r webhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	defer r.lock()()
+	delivery.UpdatedAt = time.Now()
+	for i := range r.data().deliveries {
+		if r.data().deliveries[i].ID == delivery.ID {
+			r.data().deliveries[i] = bareDelivery(*delivery)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r webhookRepository) CreateAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	defer r.lock()()
+	attempt.ID = newID(attempt.ID)
+	stamp(&attempt.CreatedAt, time.Now())
+	r.data().attempts = append(r.data().attempts, *attempt)
+	return nil
+}
+
+// bareDelivery drops loaded associations so they are never stored on the delivery
+func bareDelivery(d models.WebhookDelivery) models.WebhookDelivery {
+	d.Subscription = models.WebhookSubscription{}
+	d.WebhookAttempt = nil
+	return d
+}
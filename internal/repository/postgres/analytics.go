@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/timerange"
+	"gorm.io/gorm"
+)
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func (r analyticsRepository) FinalStatusCounts(ctx context.Context, rng timerange.Range) ([]repository.LabelCount, error) {
+	var counts []repository.LabelCount
+	err := r.db.WithContext(ctx).Model(&models.Content{}).
+		Scopes(rng.Scope("created_at")).
+		Select("final_status as label, COUNT(*) as value").
+		Group("final_status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r analyticsRepository) FinalStatusOverTime(ctx context.Context, rng timerange.Range) ([]repository.BucketCount, error) {
+	bucketExpr, bucketArgs := rng.BucketExpr(r.db.Dialector.Name(), "created_at")
+
+	var rows []bucketRow
+	err := r.db.WithContext(ctx).Model(&models.Content{}).
+		Scopes(rng.Scope("created_at")).
+		Select(bucketExpr+" as bucket, final_status as status, COUNT(*) as count", bucketArgs...).
+		Group("bucket, final_status").
+		Scan(&rows).Error
+	return fromWallClock(rng, rows), err
+}
+
+func (r analyticsRepository) ResultMediaTypeCounts(ctx context.Context, rng timerange.Range) ([]repository.LabelCount, error) {
+	var counts []repository.LabelCount
+	err := r.db.WithContext(ctx).Model(&models.ModerationResult{}).
+		Scopes(rng.Scope("created_at")).
+		Select("media_type as label, COUNT(*) as value").
+		Group("media_type").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r analyticsRepository) ResultStatusByMediaType(ctx context.Context, rng timerange.Range) ([]repository.MediaStatusCount, error) {
+	var counts []repository.MediaStatusCount
+	err := r.db.WithContext(ctx).Model(&models.ModerationResult{}).
+		Scopes(rng.Scope("created_at")).
+		Select("media_type, status, COUNT(*) as count").
+		Group("media_type, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r analyticsRepository) RiskHistogram(ctx context.Context, rng timerange.Range, edges []float64, filter repository.ResultFilter) ([]int64, error) {
+	// One CASE expression maps each score to its bucket index so the whole
+	// histogram comes back from a single grouped query
+	var (
+		bucketExpr strings.Builder
+		bucketArgs []interface{}
+	)
+	bucketExpr.WriteString("CASE")
+	last := len(edges) - 2
+	for i := 0; i <= last; i++ {
+		upper := "<"
+		if i == last {
+			upper = "<="
+		}
+		fmt.Fprintf(&bucketExpr, " WHEN risk_score >= ? AND risk_score %s ? THEN %d", upper, i)
+		bucketArgs = append(bucketArgs, edges[i], edges[i+1])
+	}
+	bucketExpr.WriteString(" END")
+
+	q := r.db.WithContext(ctx).Model(&models.ModerationResult{}).
+		Scopes(rng.Scope("created_at")).
+		Where("risk_score >= ? AND risk_score <= ?", edges[0], edges[len(edges)-1])
+	if len(filter.MediaTypes) > 0 {
+		q = q.Where("media_type IN ?", filter.MediaTypes)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Categories) > 0 {
+		q = q.Where("category IN ?", filter.Categories)
+	}
+
+	type bucketCount struct {
+		Bucket int
+		Count  int64
+	}
+	var rows []bucketCount
+	if err := q.Select(bucketExpr.String()+" as bucket, COUNT(*) as count", bucketArgs...).
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(edges)-1)
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	return counts, nil
+}
+
+func (r analyticsRepository) AuditsOverTime(ctx context.Context, rng timerange.Range) ([]repository.BucketCount, error) {
+	bucketExpr, bucketArgs := rng.BucketExpr(r.db.Dialector.Name(), "created_at")
+
+	var rows []bucketRow
+	err := r.db.WithContext(ctx).Model(&models.Audit{}).
+		Scopes(rng.Scope("created_at")).
+		Select(bucketExpr+" as bucket, COUNT(*) as count", bucketArgs...).
+		Group("bucket").
+		Scan(&rows).Error
+	return fromWallClock(rng, rows), err
+}
+
+func (r analyticsRepository) Summary(ctx context.Context, rng timerange.Range) (repository.Summary, error) {
+	db := r.db.WithContext(ctx)
+	inRange := rng.Scope("created_at")
+
+	var summary repository.Summary
+	for _, q := range []struct {
+		query *gorm.DB
+		count *int64
+	}{
+		{db.Model(&models.Content{}).Scopes(inRange), &summary.TotalContent},
+		{db.Model(&models.Content{}).Scopes(inRange).Where("final_status = ?", models.Pending), &summary.PendingCount},
+		{db.Model(&models.Content{}).Scopes(inRange).Where("final_status = ?", models.Approved), &summary.ApprovedCount},
+		{db.Model(&models.Content{}).Scopes(inRange).Where("final_status = ?", models.Rejected), &summary.RejectedCount},
+		{db.Model(&models.Content{}).Scopes(inRange).Where("final_status = ?", models.Flagged), &summary.FlaggedCount},
+		{db.Model(&models.Audit{}).Scopes(inRange), &summary.TotalAudits},
+	} {
+		if err := q.query.Count(q.count).Error; err != nil {
+			return summary, err
+		}
+	}
+
+	var avgScore struct {
+		Avg float64
+	}
+	if err := db.Model(&models.ModerationResult{}).Scopes(inRange).Select("COALESCE(AVG(risk_score), 0) as avg").Scan(&avgScore).Error; err != nil {
+		return summary, err
+	}
+	summary.AvgRiskScore = avgScore.Avg
+
+	return summary, nil
+}
+
+func (r analyticsRepository) AgreementPairs(ctx context.Context, rng timerange.Range, mediaTypes []string) ([]repository.AgreementPair, error) {
+	bucketExpr, bucketArgs := rng.BucketExpr(r.db.Dialector.Name(), "a.created_at")
+	rangeCond, rangeArgs := rng.Where("a.created_at")
+	if rangeCond == "" {
+		rangeCond = "TRUE"
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT bucket, model, policy_version, media_type, model_status, human_status, COUNT(*) AS count
+		FROM (
+			SELECT %s AS bucket,
+				mr.model, mr.policy_version, mr.media_type,
+				mr.status AS model_status,
+				CASE mr.media_type
+					WHEN 'TXT' THEN a.text_status
+					WHEN 'IMG' THEN a.image_status
+					WHEN 'VID' THEN a.video_status
+				END AS human_status
+			FROM audits a
+			JOIN moderation_results mr ON mr.content_id = a.content_id
+			WHERE %s
+				AND a.created_at = (SELECT MAX(a2.created_at) FROM audits a2 WHERE a2.content_id = a.content_id)
+		) pairs
+		WHERE human_status IS NOT NULL AND human_status <> ''`, bucketExpr, rangeCond)
+	args := append(append([]interface{}{}, bucketArgs...), rangeArgs...)
+	if len(mediaTypes) > 0 {
+		sql += " AND media_type IN ?"
+		args = append(args, mediaTypes)
+	}
+	sql += " GROUP BY bucket, model, policy_version, media_type, model_status, human_status"
+
+	var rows []struct {
+		Bucket        timerange.WallClock
+		Model         string
+		PolicyVersion string
+		MediaType     string
+		ModelStatus   string
+		HumanStatus   string
+		Count         int64
+	}
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	pairs := make([]repository.AgreementPair, len(rows))
+	for i, row := range rows {
+		pairs[i] = repository.AgreementPair{
+			Bucket:        rng.FromWallClock(row.Bucket.Time),
+			Model:         row.Model,
+			PolicyVersion: row.PolicyVersion,
+			MediaType:     row.MediaType,
+			ModelStatus:   row.ModelStatus,
+			HumanStatus:   row.HumanStatus,
+			Count:         row.Count,
+		}
+	}
+	return pairs, nil
+}
+
+func (r analyticsRepository) StageEvents(ctx context.Context, rng timerange.Range, fn func(repository.StageEvent) error) error {
+	db := r.db.WithContext(ctx)
+
+	rows, err := db.Table("moderation_events e").
+		Select("e.content_id, c.created_at AS content_created_at, e.event_type, e.media_type, e.queue, e.created_at").
+		Joins("JOIN contents c ON c.id = e.content_id").
+		Scopes(rng.Scope("c.created_at")).
+		Where("e.event_type IN ?", []models.EventType{models.Created, models.Moderated, models.Aggregated, models.HumanReviewed}).
+		Order("e.content_id, e.created_at").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event repository.StageEvent
+		if err := db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// bucketRow is a BucketCount as it comes back from a query grouping by BucketExpr
+type bucketRow struct {
+	Bucket timerange.WallClock
+	Status string
+	Count  int64
+}
+
+// fromWallClock turns the bucket timestamps returned by BucketExpr into times in the range's zone
+func fromWallClock(rng timerange.Range, rows []bucketRow) []repository.BucketCount {
+	counts := make([]repository.BucketCount, len(rows))
+	for i, row := range rows {
+		counts[i] = repository.BucketCount{Bucket: rng.FromWallClock(row.Bucket.Time), Status: row.Status, Count: row.Count}
+	}
+	return counts
+}
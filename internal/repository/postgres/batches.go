@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type batchRepository struct {
+	db *gorm.DB
+}
+
+func (r batchRepository) Create(ctx context.Context, batch *models.Batch) error {
+	return r.db.WithContext(ctx).Create(batch).Error
+}
+
+func (r batchRepository) Get(ctx context.Context, id uuid.UUID, tenantID string) (models.Batch, error) {
+	var batch models.Batch
+	err := r.db.WithContext(ctx).Where(&models.Batch{ID: id, TenantID: tenantID}).First(&batch).Error
+	return batch, notFound(err)
+}
+
+func (r batchRepository) Save(ctx context.Context, batch *models.Batch) error {
+	return r.db.WithContext(ctx).Save(batch).Error
+}
+
+func (r batchRepository) StatusCounts(ctx context.Context, id uuid.UUID) ([]repository.LabelCount, error) {
+	var counts []repository.LabelCount
+	err := r.db.WithContext(ctx).Model(&models.Content{}).
+		Select("final_status as label, COUNT(*) as value").
+		Where("batch_id = ?", id).
+		Group("final_status").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// tsQuery parses the syntax people type into search boxes: quoted
+	// phrases, OR and -excluded terms
+	tsQuery = "websearch_to_tsquery('english', ?)"
+	// headlineOptions wraps matched terms in the highlight delimiters, which
+	// repository.EscapeHighlight turns into <mark> tags once the text is escaped
+	headlineOptions = `StartSel="` + repository.HighlightStart + `", StopSel="` + repository.HighlightStop + `", MaxFragments=3, MaxWords=30, MinWords=10`
+)
+
+type contentRepository struct {
+	db *gorm.DB
+}
+
+func (r contentRepository) Create(ctx context.Context, content *models.Content) error {
+	return r.db.WithContext(ctx).Create(content).Error
+}
+
+func (r contentRepository) CreateMany(ctx context.Context, contents []models.Content) error {
+	if len(contents) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(&contents, insertBatchSize).Error
+}
+
+func (r contentRepository) Get(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	var content models.Content
+	err := r.db.WithContext(ctx).First(&content, "id = ?", id).Error
+	return content, notFound(err)
+}
+
+func (r contentRepository) GetDetailed(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	var content models.Content
+	err := preloadDetails(r.db.WithContext(ctx)).First(&content, "id = ?", id).Error
+	return content, notFound(err)
+}
+
+func (r contentRepository) GetMany(ctx context.Context, ids []uuid.UUID) ([]models.Content, error) {
+	var contents []models.Content
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&contents).Error
+	return contents, err
+}
+
+func (r contentRepository) Lock(ctx context.Context, id uuid.UUID) (models.Content, error) {
+	var content models.Content
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&content, "id = ?", id).Error
+	return content, notFound(err)
+}
+
+func (r contentRepository) Save(ctx context.Context, content *models.Content) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(content).Error
+}
+
+// DeleteMany relies on the foreign keys to remove the child records
+func (r contentRepository) DeleteMany(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&models.Content{}).Error
+}
+
+func (r contentRepository) List(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error) {
+	var contents []models.Content
+	err := page.Apply(filter.Apply(r.db.WithContext(ctx).Model(&models.Content{}))).Find(&contents).Error
+	return contents, err
+}
+
+func (r contentRepository) ListDetailed(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error) {
+	var contents []models.Content
+	err := preloadDetails(page.Apply(filter.Apply(r.db.WithContext(ctx).Model(&models.Content{})))).Find(&contents).Error
+	return contents, err
+}
+
+func (r contentRepository) Count(ctx context.Context, filter listing.ContentFilter) (int64, error) {
+	var total int64
+	err := filter.Apply(r.db.WithContext(ctx).Model(&models.Content{})).Count(&total).Error
+	return total, err
+}
+
+func (r contentRepository) Search(ctx context.Context, query repository.SearchQuery) ([]repository.SearchMatch, error) {
+	q := query.Q
+	textMatch := "contents.search_vector @@ " + tsQuery
+	textRank := "ts_rank(contents.search_vector, " + tsQuery + ")"
+	explanationMatch := "EXISTS (SELECT 1 FROM moderation_results mr WHERE mr.content_id = contents.id AND mr.explanation_vector @@ " + tsQuery + ")"
+	explanationRank := "COALESCE((SELECT MAX(ts_rank(mr.explanation_vector, " + tsQuery + ")) FROM moderation_results mr WHERE mr.content_id = contents.id), 0)"
+
+	var (
+		where     string
+		rank      string
+		whereArgs []interface{}
+		rankArgs  []interface{}
+	)
+	switch query.In {
+	case repository.SearchText:
+		where, whereArgs = textMatch, []interface{}{q}
+		rank, rankArgs = textRank, []interface{}{q}
+	case repository.SearchExplanation:
+		where, whereArgs = explanationMatch, []interface{}{q}
+		rank, rankArgs = explanationRank, []interface{}{q}
+	default:
+		where, whereArgs = fmt.Sprintf("(%s OR %s)", textMatch, explanationMatch), []interface{}{q, q}
+		rank, rankArgs = fmt.Sprintf("(%s + %s)", textRank, explanationRank), []interface{}{q, q}
+	}
+
+	type rankedRow struct {
+		ID            uuid.UUID
+		Rank          float64
+		TextHighlight string
+	}
+
+	// Only highlight the text when it matched; ts_headline would otherwise
+	// return the start of the document
+	selectArgs := append(rankArgs, q, q, headlineOptions)
+	var rows []rankedRow
+	err := query.Filter.Apply(r.db.WithContext(ctx).Model(&models.Content{})).
+		Select(fmt.Sprintf("contents.id, %s AS rank, CASE WHEN %s THEN ts_headline('english', contents.text, %s, ?) ELSE '' END AS text_highlight", rank, textMatch, tsQuery), selectArgs...).
+		Where(where, whereArgs...).
+		Order("rank DESC, contents.created_at DESC").
+		Limit(query.Limit + 1).
+		Offset(query.Offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]repository.SearchMatch, len(rows))
+	for i, row := range rows {
+		matches[i] = repository.SearchMatch{ContentID: row.ID, Rank: row.Rank, TextHighlight: repository.EscapeHighlight(row.TextHighlight)}
+	}
+	return matches, nil
+}
+
+// preloadDetails loads the results, events and audits of the queried content
+func preloadDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("ModerationResult", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("ModerationEvents", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Audit", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") })
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type exportRepository struct {
+	db *gorm.DB
+}
+
+func (r exportRepository) Create(ctx context.Context, export *models.Export) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r exportRepository) Get(ctx context.Context, id uuid.UUID) (models.Export, error) {
+	var export models.Export
+	err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error
+	return export, notFound(err)
+}
+
+func (r exportRepository) List(ctx context.Context, tenantID string, limit int) ([]models.Export, error) {
+	var exports []models.Export
+	err := r.db.WithContext(ctx).Where(&models.Export{TenantID: tenantID}).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r exportRepository) Save(ctx context.Context, export *models.Export) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
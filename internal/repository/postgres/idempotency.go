@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func (r idempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	db := r.db.WithContext(ctx)
+
+	// A second attempt follows a held key being deleted, by this call
+	// because it expired or by another one
+	for range 2 {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+		if result.Error != nil {
+			return models.IdempotencyKey{}, false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return models.IdempotencyKey{}, true, nil
+		}
+
+		var held models.IdempotencyKey
+		err := db.First(&held, "tenant_id = ? AND key = ?", key.TenantID, key.Key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return models.IdempotencyKey{}, false, err
+		}
+		if held.ExpiresAt.After(time.Now()) {
+			return held, false, nil
+		}
+
+		if err := db.Where("tenant_id = ? AND key = ? AND expires_at <= ?", key.TenantID, key.Key, time.Now()).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return models.IdempotencyKey{}, false, err
+		}
+	}
+	return models.IdempotencyKey{}, false, errors.New("idempotency key changed while it was being reserved")
+}
+
+func (r idempotencyRepository) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("tenant_id = ? AND key = ?", key.TenantID, key.Key).
+		Updates(map[string]interface{}{
+			"status_code":   key.StatusCode,
+			"content_type":  key.ContentType,
+			"response_body": key.ResponseBody,
+			"expires_at":    key.ExpiresAt,
+		}).Error
+}
+
+func (r idempotencyRepository) Release(ctx context.Context, tenantID, key string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND key = ? AND status_code = 0", tenantID, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type blocklistRepository struct {
+	db *gorm.DB
+}
+
+func (r blocklistRepository) List(ctx context.Context, tenantID string) ([]models.BlocklistEntry, error) {
+	query := r.db.WithContext(ctx).Order("tenant_id, created_at")
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	var entries []models.BlocklistEntry
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+func (r blocklistRepository) Create(ctx context.Context, entry *models.BlocklistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r blocklistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.BlocklistEntry{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+type policyRepository struct {
+	db *gorm.DB
+}
+
+func (r policyRepository) List(ctx context.Context, tenantID string) ([]models.Policy, error) {
+	query := r.db.WithContext(ctx).Order("tenant_id, media_type, created_at")
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	var policies []models.Policy
+	err := query.Find(&policies).Error
+	return policies, err
+}
+
+func (r policyRepository) Get(ctx context.Context, id uuid.UUID) (models.Policy, error) {
+	var policy models.Policy
+	err := r.db.WithContext(ctx).First(&policy, "id = ?", id).Error
+	return policy, notFound(err)
+}
+
+func (r policyRepository) Active(ctx context.Context, tenantID string, mediaType models.MediaType) (*models.Policy, error) {
+	var policy models.Policy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND media_type = ? AND active", tenantID, mediaType).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r policyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	return r.db.WithContext(ctx).Create(policy).Error
+}
+
+func (r policyRepository) Activate(ctx context.Context, id uuid.UUID) (models.Policy, error) {
+	var policy models.Policy
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&policy, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Policy{}).
+			Where("tenant_id = ? AND media_type = ? AND id <> ?", policy.TenantID, policy.MediaType, policy.ID).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		policy.Active = true
+		return tx.Model(&policy).Update("active", true).Error
+	})
+	return policy, notFound(err)
+}
+
+func (r policyRepository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.Policy{}).Where("id = ?", id).Update("active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package postgres implements the repositories over GORM and Postgres
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"gorm.io/gorm"
+)
+
+// insertBatchSize is how many rows go into a single INSERT
+const insertBatchSize = 100
+
+// New returns repositories that run their queries on db
+func New(db *gorm.DB) *repository.Repositories {
+	return &repository.Repositories{
+		Contents:    contentRepository{db},
+		Results:     resultRepository{db},
+		Events:      eventRepository{db},
+		Audits:      auditRepository{db},
+		Analytics:   analyticsRepository{db},
+		Batches:     batchRepository{db},
+		Blocklist:   blocklistRepository{db},
+		Policies:    policyRepository{db},
+		Webhooks:    webhookRepository{db},
+		Exports:     exportRepository{db},
+		Idempotency: idempotencyRepository{db},
+		Usage:       usageRepository{db},
+		Transactor:  transactor{db},
+	}
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func (t transactor) Transaction(ctx context.Context, fn func(tx *repository.Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
+// notFound maps GORM's missing-record error to the repository one
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type resultRepository struct {
+	db *gorm.DB
+}
+
+func (r resultRepository) Create(ctx context.Context, result *models.ModerationResult) error {
+	return r.db.WithContext(ctx).Omit("Content").Create(result).Error
+}
+
+func (r resultRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationResult, error) {
+	var results []models.ModerationResult
+	err := r.db.WithContext(ctx).Where("content_id = ?", contentID).Order("created_at").Find(&results).Error
+	return results, err
+}
+
+func (r resultRepository) SearchExplanations(ctx context.Context, contentIDs []uuid.UUID, q string) ([]repository.ExplanationMatch, error) {
+	var matches []repository.ExplanationMatch
+	err := r.db.WithContext(ctx).Model(&models.ModerationResult{}).
+		Select("id AS result_id, content_id, media_type, status, ts_headline('english', explaination, "+tsQuery+", ?) AS highlight", q, headlineOptions).
+		Where("content_id IN ? AND explanation_vector @@ "+tsQuery, contentIDs, q).
+		Scan(&matches).Error
+	for i := range matches {
+		matches[i].Highlight = repository.EscapeHighlight(matches[i].Highlight)
+	}
+	return matches, err
+}
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func (r eventRepository) Create(ctx context.Context, event *models.ModerationEvents) error {
+	return r.db.WithContext(ctx).Omit("Content").Create(event).Error
+}
+
+func (r eventRepository) CreateMany(ctx context.Context, events []models.ModerationEvents) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Content").CreateInBatches(&events, insertBatchSize).Error
+}
+
+func (r eventRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationEvents, error) {
+	var events []models.ModerationEvents
+	err := r.db.WithContext(ctx).Where("content_id = ?", contentID).Order("created_at").Find(&events).Error
+	return events, err
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func (r auditRepository) Create(ctx context.Context, audit *models.Audit) error {
+	return r.db.WithContext(ctx).Omit("Content").Create(audit).Error
+}
+
+func (r auditRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.Audit, error) {
+	var audits []models.Audit
+	err := r.db.WithContext(ctx).Where("content_id = ?", contentID).Order("created_at").Find(&audits).Error
+	return audits, err
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func (r usageRepository) Record(ctx context.Context, call *models.ModelCall) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(call).Error; err != nil {
+			return err
+		}
+
+		created := call.CreatedAt.UTC()
+		day := models.ModelUsageDay{
+			TenantID:     call.TenantID,
+			Day:          time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC),
+			Model:        call.Model,
+			Calls:        1,
+			InputTokens:  call.InputTokens,
+			OutputTokens: call.OutputTokens,
+			Cost:         call.Cost,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "day"}, {Name: "model"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"calls":         gorm.Expr("model_usage_days.calls + excluded.calls"),
+				"input_tokens":  gorm.Expr("model_usage_days.input_tokens + excluded.input_tokens"),
+				"output_tokens": gorm.Expr("model_usage_days.output_tokens + excluded.output_tokens"),
+				"cost":          gorm.Expr("model_usage_days.cost + excluded.cost"),
+			}),
+		}).Create(&day).Error
+	})
+}
+
+func (r usageRepository) Days(ctx context.Context, tenantID string, from, to time.Time) ([]models.ModelUsageDay, error) {
+	var days []models.ModelUsageDay
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND day >= ? AND day < ?", tenantID, from, to).
+		Order("day, model").
+		Find(&days).Error
+	return days, err
+}
+
+func (r usageRepository) Cost(ctx context.Context, tenantID string, from, to time.Time) (float64, error) {
+	var cost float64
+	err := r.db.WithContext(ctx).Model(&models.ModelUsageDay{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("tenant_id = ? AND day >= ? AND day < ?", tenantID, from, to).
+		Scan(&cost).Error
+	return cost, err
+}
+
+func (r usageRepository) ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModelCall, error) {
+	var calls []models.ModelCall
+	err := r.db.WithContext(ctx).Where("content_id = ?", contentID).Order("created_at").Find(&calls).Error
+	return calls, err
+}
+
+func (r usageRepository) CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r usageRepository) ListAlerts(ctx context.Context, tenantID string) ([]models.BudgetAlert, error) {
+	var alerts []models.BudgetAlert
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("month DESC").Find(&alerts).Error
+	return alerts, err
+}
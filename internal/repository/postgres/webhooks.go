@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func (r webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r webhookRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where(&models.WebhookSubscription{TenantID: tenantID}).Order("created_at").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r webhookRepository) ActiveSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where(&models.WebhookSubscription{TenantID: tenantID, Active: true}).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID, tenantID string) (models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where(&models.WebhookSubscription{ID: id, TenantID: tenantID}).First(&subscription).Error
+	return subscription, notFound(err)
+}
+
+func (r webhookRepository) DeactivateSubscription(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.WebhookSubscription{ID: id}).Update("active", false).Error
+}
+
+func (r webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(delivery).Error
+}
+
+func (r webhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("Subscription").
+		Preload("WebhookAttempt", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&delivery, "id = ?", id).Error
+	return delivery, notFound(err)
+}
+
+func (r webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where(&models.WebhookDelivery{SubscriptionID: subscriptionID}).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r webhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(delivery).Error
+}
+
+func (r webhookRepository) CreateAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
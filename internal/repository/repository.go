@@ -0,0 +1,221 @@
+// Package repository defines the storage interfaces the handlers and workers
+// use instead of reaching into the database directly. The postgres package
+// implements them over GORM, the sqlite package adapts that implementation to
+// SQLite, and the memory package keeps everything in process for tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/listing"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a lookup by ID matches nothing
+var ErrNotFound = errors.New("record not found")
+
+// Repositories groups every repository of one backend
+type Repositories struct {
+	Contents    ContentRepository
+	Results     ResultRepository
+	Events      EventRepository
+	Audits      AuditRepository
+	Analytics   AnalyticsRepository
+	Batches     BatchRepository
+	Blocklist   BlocklistRepository
+	Policies    PolicyRepository
+	Webhooks    WebhookRepository
+	Exports     ExportRepository
+	Idempotency IdempotencyRepository
+	Usage       UsageRepository
+	Transactor
+}
+
+// Transactor runs fn with repositories bound to a single transaction, which
+// is committed when fn returns nil and rolled back otherwise
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(tx *Repositories) error) error
+}
+
+type ContentRepository interface {
+	// Create assigns the content its ID and timestamps
+	Create(ctx context.Context, content *models.Content) error
+	CreateMany(ctx context.Context, contents []models.Content) error
+	Get(ctx context.Context, id uuid.UUID) (models.Content, error)
+	// GetDetailed also loads the content's results, events and audits
+	GetDetailed(ctx context.Context, id uuid.UUID) (models.Content, error)
+	GetMany(ctx context.Context, ids []uuid.UUID) ([]models.Content, error)
+	// Lock reads the content and, inside a transaction, keeps other
+	// transactions from changing it until this one ends
+	Lock(ctx context.Context, id uuid.UUID) (models.Content, error)
+	Save(ctx context.Context, content *models.Content) error
+	// DeleteMany removes content items with their results, events and audits
+	DeleteMany(ctx context.Context, ids []uuid.UUID) error
+	// List returns one page of matching content, plus one extra item when
+	// another page follows, for page.Next to trim
+	List(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error)
+	// ListDetailed is List with each item's results, events and audits loaded
+	ListDetailed(ctx context.Context, filter listing.ContentFilter, page listing.Page) ([]models.Content, error)
+	Count(ctx context.Context, filter listing.ContentFilter) (int64, error)
+	// Search ranks matching content by relevance, with the same extra item as List
+	Search(ctx context.Context, query SearchQuery) ([]SearchMatch, error)
+}
+
+type ResultRepository interface {
+	Create(ctx context.Context, result *models.ModerationResult) error
+	ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationResult, error)
+	// SearchExplanations returns the results of the given content whose explanation matches q
+	SearchExplanations(ctx context.Context, contentIDs []uuid.UUID, q string) ([]ExplanationMatch, error)
+}
+
+type EventRepository interface {
+	Create(ctx context.Context, event *models.ModerationEvents) error
+	CreateMany(ctx context.Context, events []models.ModerationEvents) error
+	ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModerationEvents, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, audit *models.Audit) error
+	ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.Audit, error)
+}
+
+type BatchRepository interface {
+	Create(ctx context.Context, batch *models.Batch) error
+	// Get only finds batches belonging to the tenant
+	Get(ctx context.Context, id uuid.UUID, tenantID string) (models.Batch, error)
+	Save(ctx context.Context, batch *models.Batch) error
+	// StatusCounts counts the batch's content by final status
+	StatusCounts(ctx context.Context, id uuid.UUID) ([]LabelCount, error)
+}
+
+type BlocklistRepository interface {
+	// List returns the tenant's entries oldest first, or every tenant's when tenantID is empty
+	List(ctx context.Context, tenantID string) ([]models.BlocklistEntry, error)
+	Create(ctx context.Context, entry *models.BlocklistEntry) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type PolicyRepository interface {
+	// List returns the tenant's policies, or every tenant's when tenantID is empty
+	List(ctx context.Context, tenantID string) ([]models.Policy, error)
+	Get(ctx context.Context, id uuid.UUID) (models.Policy, error)
+	// Active returns the tenant's active policy for the media type, or nil when there is none
+	Active(ctx context.Context, tenantID string, mediaType models.MediaType) (*models.Policy, error)
+	Create(ctx context.Context, policy *models.Policy) error
+	// Activate makes the policy the only active one for its tenant and media type
+	Activate(ctx context.Context, id uuid.UUID) (models.Policy, error)
+	Deactivate(ctx context.Context, id uuid.UUID) error
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error)
+	ActiveSubscriptions(ctx context.Context, tenantID string) ([]models.WebhookSubscription, error)
+	// GetSubscription only finds subscriptions belonging to the tenant
+	GetSubscription(ctx context.Context, id uuid.UUID, tenantID string) (models.WebhookSubscription, error)
+	DeactivateSubscription(ctx context.Context, id uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetDelivery loads the delivery with its subscription and attempt log
+	GetDelivery(ctx context.Context, id uuid.UUID) (models.WebhookDelivery, error)
+	// ListDeliveries returns the subscription's latest deliveries, newest first
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	// SaveDelivery updates the delivery's own fields, leaving its associations alone
+	SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	CreateAttempt(ctx context.Context, attempt *models.WebhookAttempt) error
+}
+
+type ExportRepository interface {
+	Create(ctx context.Context, export *models.Export) error
+	Get(ctx context.Context, id uuid.UUID) (models.Export, error)
+	// List returns the tenant's latest exports, newest first
+	List(ctx context.Context, tenantID string, limit int) ([]models.Export, error)
+	Save(ctx context.Context, export *models.Export) error
+}
+
+type IdempotencyRepository interface {
+	// Reserve records key as in progress and reports true, unless the tenant
+	// holds the same key already; then it returns the held one and false. A
+	// held key past its ExpiresAt is replaced.
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (models.IdempotencyKey, bool, error)
+	// Complete stores the response and expiry of a reserved key
+	Complete(ctx context.Context, key *models.IdempotencyKey) error
+	// Release deletes a key still in progress, so the request can be made again
+	Release(ctx context.Context, tenantID, key string) error
+	// DeleteExpired deletes the keys that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type UsageRepository interface {
+	// Record stores the model call and adds it to its tenant's total for the
+	// UTC day it was made
+	Record(ctx context.Context, call *models.ModelCall) error
+	// Days returns the tenant's daily totals for days in [from, to), by day then model
+	Days(ctx context.Context, tenantID string, from, to time.Time) ([]models.ModelUsageDay, error)
+	// Cost sums the tenant's cost over days in [from, to)
+	Cost(ctx context.Context, tenantID string, from, to time.Time) (float64, error)
+	// ListByContent returns the model calls made for the content, oldest first
+	ListByContent(ctx context.Context, contentID uuid.UUID) ([]models.ModelCall, error)
+	// CreateAlert records the alert and reports true, unless the tenant
+	// already has one for the month
+	CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error)
+	// ListAlerts returns the tenant's alerts, newest first
+	ListAlerts(ctx context.Context, tenantID string) ([]models.BudgetAlert, error)
+}
+
+// SearchScope is where a search looks: 'all', 'text', 'explanation'
+type SearchScope string
+
+const (
+	SearchAll         SearchScope = "all"
+	SearchText        SearchScope = "text"
+	SearchExplanation SearchScope = "explanation"
+)
+
+type SearchQuery struct {
+	Q      string
+	In     SearchScope
+	Filter listing.ContentFilter
+	Limit  int
+	Offset int
+}
+
+// Delimiters the stores wrap matched terms in. They are private use
+// characters rather than tags, so the fragment can be HTML-escaped first.
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+var highlightTags = strings.NewReplacer(HighlightStart, "<mark>", HighlightStop, "</mark>")
+
+// EscapeHighlight HTML-escapes a highlighted fragment of submitted text and
+// turns its delimiters into <mark> tags, the only markup it may then contain
+func EscapeHighlight(fragment string) string {
+	return highlightTags.Replace(html.EscapeString(fragment))
+}
+
+// SearchMatch is one ranked search result. TextHighlight is the HTML-escaped
+// text with matched terms in <mark> tags, and is empty when only
+// explanations matched.
+type SearchMatch struct {
+	ContentID     uuid.UUID
+	Rank          float64
+	TextHighlight string
+}
+
+// ExplanationMatch is a result whose explanation matched; Highlight is
+// escaped and marked like SearchMatch.TextHighlight
+type ExplanationMatch struct {
+	ResultID  uuid.UUID
+	ContentID uuid.UUID
+	MediaType models.MediaType
+	Status    models.ContentStatus
+	Highlight string
+}
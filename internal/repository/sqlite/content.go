@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// snippetArgs wrap matched terms in the highlight delimiters, like the
+	// Postgres headline options, around a fragment of up to 30 words
+	snippetArgs = "'" + repository.HighlightStart + "', '" + repository.HighlightStop + "', '…', 30"
+	// Matches and ranks of a content's text and its results' explanations.
+	// bm25 is lower for better matches, so it is negated to sort like ts_rank;
+	// FTS5 doesn't allow it in aggregates, so the best explanation is picked
+	// by ordering instead of MAX.
+	textMatch        = "contents.id IN (SELECT id FROM contents_fts WHERE contents_fts MATCH ?)"
+	textRank         = "COALESCE((SELECT -bm25(contents_fts) FROM contents_fts WHERE contents_fts MATCH ? AND contents_fts.id = contents.id), 0)"
+	textSnippet      = "COALESCE((SELECT snippet(contents_fts, 1, " + snippetArgs + ") FROM contents_fts WHERE contents_fts MATCH ? AND contents_fts.id = contents.id), '')"
+	explanationMatch = "contents.id IN (SELECT content_id FROM moderation_results_fts WHERE moderation_results_fts MATCH ?)"
+	explanationRank  = "COALESCE((SELECT -bm25(moderation_results_fts) FROM moderation_results_fts WHERE moderation_results_fts MATCH ? AND moderation_results_fts.content_id = contents.id ORDER BY bm25(moderation_results_fts) LIMIT 1), 0)"
+)
+
+// contentRepository replaces the Postgres full-text search of the content repository
+type contentRepository struct {
+	repository.ContentRepository
+	db *gorm.DB
+}
+
+func (r contentRepository) Search(ctx context.Context, query repository.SearchQuery) ([]repository.SearchMatch, error) {
+	q := ftsQuery(query.Q)
+	if q == "" {
+		return nil, nil
+	}
+
+	var (
+		where     string
+		rank      string
+		whereArgs []interface{}
+		rankArgs  []interface{}
+	)
+	switch query.In {
+	case repository.SearchText:
+		where, whereArgs = textMatch, []interface{}{q}
+		rank, rankArgs = textRank, []interface{}{q}
+	case repository.SearchExplanation:
+		where, whereArgs = explanationMatch, []interface{}{q}
+		rank, rankArgs = explanationRank, []interface{}{q}
+	default:
+		where, whereArgs = fmt.Sprintf("(%s OR %s)", textMatch, explanationMatch), []interface{}{q, q}
+		rank, rankArgs = fmt.Sprintf("(%s + %s)", textRank, explanationRank), []interface{}{q, q}
+	}
+
+	type rankedRow struct {
+		ID            uuid.UUID
+		Rank          float64
+		TextHighlight string
+	}
+
+	// The snippet is empty when the text didn't match
+	selectArgs := append(rankArgs, q)
+	var rows []rankedRow
+	err := query.Filter.Apply(r.db.WithContext(ctx).Model(&models.Content{})).
+		Select(fmt.Sprintf("contents.id, %s AS rank, %s AS text_highlight", rank, textSnippet), selectArgs...).
+		Where(where, whereArgs...).
+		Order("rank DESC, contents.created_at DESC").
+		Limit(query.Limit + 1).
+		Offset(query.Offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]repository.SearchMatch, len(rows))
+	for i, row := range rows {
+		matches[i] = repository.SearchMatch{ContentID: row.ID, Rank: row.Rank, TextHighlight: repository.EscapeHighlight(row.TextHighlight)}
+	}
+	return matches, nil
+}
@@ -0,0 +1,33 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// resultRepository replaces the Postgres full-text search of the result repository
+type resultRepository struct {
+	repository.ResultRepository
+	db *gorm.DB
+}
+
+func (r resultRepository) SearchExplanations(ctx context.Context, contentIDs []uuid.UUID, q string) ([]repository.ExplanationMatch, error) {
+	expr := ftsQuery(q)
+	if expr == "" {
+		return nil, nil
+	}
+
+	var matches []repository.ExplanationMatch
+	err := r.db.WithContext(ctx).Table("moderation_results_fts").
+		Select("mr.id AS result_id, mr.content_id, mr.media_type, mr.status, snippet(moderation_results_fts, 2, "+snippetArgs+") AS highlight").
+		Joins("JOIN moderation_results mr ON mr.id = moderation_results_fts.id").
+		Where("moderation_results_fts MATCH ? AND moderation_results_fts.content_id IN ?", expr, contentIDs).
+		Scan(&matches).Error
+	for i := range matches {
+		matches[i].Highlight = repository.EscapeHighlight(matches[i].Highlight)
+	}
+	return matches, err
+}
@@ -0,0 +1,95 @@
+// Package sqlite implements the repositories over GORM and SQLite. Most
+// queries are portable, so it reuses the postgres repositories and only
+// replaces full-text search, which runs on the FTS5 tables created by the
+// SQLite migrations.
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/Sreejit-Sengupto/internal/repository/postgres"
+	"gorm.io/gorm"
+)
+
+// New returns repositories that run their queries on db
+func New(db *gorm.DB) *repository.Repositories {
+	repos := postgres.New(db)
+	repos.Contents = contentRepository{ContentRepository: repos.Contents, db: db}
+	repos.Results = resultRepository{ResultRepository: repos.Results, db: db}
+	repos.Transactor = transactor{db}
+	return repos
+}
+
+// transactor hands fn SQLite repositories; the postgres one would bind the
+// transaction to its own. Transactions take the database's write lock when
+// they begin, which also makes Lock hold until they end.
+type transactor struct {
+	db *gorm.DB
+}
+
+func (t transactor) Transaction(ctx context.Context, fn func(tx *repository.Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
+// ftsQuery translates the syntax websearch_to_tsquery accepts into an FTS5
+// query: words must all match, "quoted phrases" match in order, OR separates
+// alternatives and -word excludes. Every term is quoted so FTS5 doesn't read
+// it as an operator. FTS5 can't match on exclusions alone, so alternatives
+// without a word to look for are dropped, and an empty result matches nothing.
+func ftsQuery(q string) string {
+	var (
+		groups         []string
+		include, avoid []string
+	)
+	endGroup := func() {
+		if len(include) > 0 {
+			group := strings.Join(include, " AND ")
+			for _, term := range avoid {
+				group += " NOT " + term
+			}
+			groups = append(groups, "("+group+")")
+		}
+		include, avoid = nil, nil
+	}
+
+	for rest := strings.TrimSpace(q); rest != ""; rest = strings.TrimSpace(rest) {
+		negate := false
+		if rest[0] == '-' {
+			negate, rest = true, rest[1:]
+		}
+
+		var term string
+		if strings.HasPrefix(rest, `"`) {
+			// An unclosed quote runs to the end of the query
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			term, rest = phrase, after
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			term, rest = rest[:end], rest[end:]
+			if !negate && strings.EqualFold(term, "or") {
+				endGroup()
+				continue
+			}
+		}
+
+		term = strings.TrimSpace(term)
+		if term == "" || strings.Trim(term, "-") == "" {
+			continue
+		}
+		quoted := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if negate {
+			avoid = append(avoid, quoted)
+		} else {
+			include = append(include, quoted)
+		}
+	}
+	endGroup()
+	return strings.Join(groups, " OR ")
+}
@@ -0,0 +1,217 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"github.com/Sreejit-Sengupto/internal/logging"
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// channel is the Redis pub/sub channel every API and worker instance publishes to
+const channel = "moderation:events"
+
+// 'content.created', 'content.result', 'content.final_status', 'content.overridden'
+type EventType string
+
+const (
+	ContentCreated    EventType = "content.created"
+	ModalityResult    EventType = "content.result"
+	FinalStatus       EventType = "content.final_status"
+	ContentOverridden EventType = "content.overridden"
+)
+
+type Event struct {
+	Type      EventType            `json:"type"`
+	ContentID uuid.UUID            `json:"contentId"`
+	TenantID  string               `json:"tenantId"`
+	MediaType models.MediaType     `json:"mediaType,omitempty"`
+	Status    models.ContentStatus `json:"status,omitempty"`
+	RiskScore *float64             `json:"riskScore,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// Filter narrows a subscription down to the events a client asked for
+type Filter struct {
+	TenantID  string
+	ContentID *uuid.UUID
+	Statuses  map[models.ContentStatus]bool
+	Types     map[EventType]bool
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.TenantID != "" && e.TenantID != f.TenantID {
+		return false
+	}
+	if f.ContentID != nil && e.ContentID != *f.ContentID {
+		return false
+	}
+	if len(f.Statuses) > 0 && !f.Statuses[e.Status] {
+		return false
+	}
+	if len(f.Types) > 0 && !f.Types[e.Type] {
+		return false
+	}
+	return true
+}
+
+// RedisClient is nil when no Redis is configured, in which case events only
+// reach subscribers of this process
+var RedisClient *redis.Client
+
+func InitStream(cfg config.RedisConfig) {
+	if cfg.URL == "" {
+		slog.Info("event stream running in-process, no Redis configured")
+		return
+	}
+
+	opt, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		log.Fatalf("Failed to parse REDIS_URL: %v", err)
+		return
+	}
+
+	RedisClient = redis.NewClient(opt)
+	slog.Info("event stream client initialized")
+}
+
+// Ping checks that the pub/sub Redis instance is reachable
+func Ping(ctx context.Context) error {
+	if RedisClient == nil {
+		return nil
+	}
+	return RedisClient.Ping(ctx).Err()
+}
+
+func CloseStream() {
+	if RedisClient != nil {
+		RedisClient.Close()
+	}
+}
+
+// Publish broadcasts an event to every instance subscribed to the stream
+func Publish(ctx context.Context, event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to marshal stream event", "error", err)
+		return
+	}
+
+	if RedisClient == nil {
+		local.publish(data)
+		return
+	}
+	if err := RedisClient.Publish(ctx, channel, data).Err(); err != nil {
+		logging.FromContext(ctx).Error("failed to publish stream event", "content_id", event.ContentID, "error", err)
+	}
+}
+
+// Subscribe returns the events matching the filter until ctx is cancelled
+func Subscribe(ctx context.Context, filter Filter) (<-chan Event, error) {
+	var messages <-chan string
+	var unsubscribe func()
+	if RedisClient == nil {
+		messages, unsubscribe = local.subscribe()
+	} else {
+		sub := RedisClient.Subscribe(ctx, channel)
+
+		// Wait for the subscription to be confirmed so no event is missed
+		if _, err := sub.Receive(ctx); err != nil {
+			sub.Close()
+			return nil, fmt.Errorf("failed to subscribe to event stream: %v", err)
+		}
+
+		payloads := make(chan string)
+		go func() {
+			defer close(payloads)
+			for msg := range sub.Channel() {
+				select {
+				case payloads <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		messages, unsubscribe = payloads, func() { sub.Close() }
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(msg), &event); err != nil {
+					logging.FromContext(ctx).Error("failed to decode stream event", "error", err)
+					continue
+				}
+				if !filter.Match(event) {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// subscriberBuffer is how many events a slow in-process subscriber may fall
+// behind before events are dropped for it
+const subscriberBuffer = 64
+
+// hub fans events out to the subscribers of this process when there is no Redis
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan string]struct{}
+}
+
+var local = &hub{subs: map[chan string]struct{}{}}
+
+func (h *hub) subscribe() (<-chan string, func()) {
+	ch := make(chan string, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *hub) publish(data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- string(data):
+		default:
+			slog.Warn("dropped stream event for slow subscriber")
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package timerange
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 'hour', 'day', 'week', 'month'
+type Granularity string
+
+const (
+	Hour  Granularity = "hour"
+	Day   Granularity = "day"
+	Week  Granularity = "week"
+	Month Granularity = "month"
+)
+
+// MaxBuckets keeps a fine granularity over a long range from producing huge responses
+const MaxBuckets = 2000
+
+// Range is the from/to/granularity/tz window shared by the analytics endpoints.
+// From and To are zero when the caller asked for no bound and the endpoint allows it.
+type Range struct {
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Granularity Granularity    `json:"granularity"`
+	Location    *time.Location `json:"-"`
+	TZ          string         `json:"tz"`
+}
+
+// Parse reads the from, to, granularity and tz query parameters. When neither
+// bound is given the range covers defaultSpan up to now; a zero defaultSpan
+// leaves the range unbounded.
+func Parse(q url.Values, defaultSpan time.Duration) (Range, error) {
+	r := Range{Granularity: Day, Location: time.UTC, TZ: "UTC"}
+
+	if tz := q.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return r, fmt.Errorf("invalid tz %q", tz)
+		}
+		r.Location, r.TZ = loc, tz
+	}
+
+	switch g := Granularity(q.Get("granularity")); g {
+	case "":
+	case Hour, Day, Week, Month:
+		r.Granularity = g
+	default:
+		return r, fmt.Errorf("invalid granularity %q, expected hour, day, week or month", g)
+	}
+
+	var err error
+	if r.From, _, err = ParseBound(q.Get("from"), r.Location); err != nil {
+		return r, fmt.Errorf("invalid from: %v", err)
+	}
+	var dateOnly bool
+	if r.To, dateOnly, err = ParseBound(q.Get("to"), r.Location); err != nil {
+		return r, fmt.Errorf("invalid to: %v", err)
+	}
+	if dateOnly {
+		r.To = EndOfDay(r.To)
+	}
+
+	if r.From.IsZero() && r.To.IsZero() && defaultSpan > 0 {
+		r.To = time.Now().In(r.Location)
+		r.From = r.To.Add(-defaultSpan)
+	} else if !r.From.IsZero() && r.To.IsZero() {
+		r.To = time.Now().In(r.Location)
+	} else if r.From.IsZero() && !r.To.IsZero() && defaultSpan > 0 {
+		r.From = r.To.Add(-defaultSpan)
+	}
+
+	if !r.From.IsZero() && !r.To.IsZero() {
+		if !r.From.Before(r.To) {
+			return r, fmt.Errorf("from must be before to")
+		}
+		if n := r.countBuckets(); n > MaxBuckets {
+			return r, fmt.Errorf("range spans %d %s buckets, more than %d; use a coarser granularity", n, r.Granularity, MaxBuckets)
+		}
+	}
+
+	return r, nil
+}
+
+// Bounded reports whether both ends of the range are set
+func (r Range) Bounded() bool {
+	return !r.From.IsZero() && !r.To.IsZero()
+}
+
+// Where returns the condition limiting column to the range, or an empty
+// string when the range is unbounded
+func (r Range) Where(column string) (string, []interface{}) {
+	switch {
+	case r.From.IsZero() && r.To.IsZero():
+		return "", nil
+	case r.From.IsZero():
+		return column + " < ?", []interface{}{r.To}
+	default:
+		return column + " >= ? AND " + column + " < ?", []interface{}{r.From, r.To}
+	}
+}
+
+// Scope limits a query to the range on the given column, for use with db.Scopes
+func (r Range) Scope(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := r.Where(column)
+		if cond == "" {
+			return db
+		}
+		return db.Where(cond, args...)
+	}
+}
+
+// BucketExpr returns the SQL expression truncating column to the start of its
+// bucket in the range's timezone, for the named GORM dialect. The result is a
+// wall-clock time in that zone, to be scanned into a WallClock.
+func (r Range) BucketExpr(dialect, column string) (string, []interface{}) {
+	if dialect != "sqlite" {
+		return "date_trunc(?, " + column + " AT TIME ZONE ?)", []interface{}{string(r.Granularity), r.TZ}
+	}
+
+	// SQLite knows no timezones besides the process's own, so the column is
+	// shifted by the offset of each zone period the range covers
+	local, args := r.sqliteLocalExpr(column)
+	switch r.Granularity {
+	case Hour:
+		return "strftime('%Y-%m-%d %H:00:00', " + local + ")", args
+	case Week:
+		// Back to the Monday on or before the date, matching date_trunc('week', ...)
+		return "date(" + local + ", '-6 days', 'weekday 1')", args
+	case Month:
+		return "strftime('%Y-%m-01', " + local + ")", args
+	default:
+		return "date(" + local + ")", args
+	}
+}
+
+// sqliteLocalExpr converts column from UTC to the range's wall clock. Without
+// both bounds it uses the offset at the bound it has, or at the current time.
+func (r Range) sqliteLocalExpr(column string) (string, []interface{}) {
+	shift := func(offset int) string {
+		return fmt.Sprintf("datetime(%s, '%+d seconds')", column, offset)
+	}
+
+	at := r.From
+	if at.IsZero() {
+		at = r.To
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.In(r.Location)
+	if !r.Bounded() {
+		_, offset := at.Zone()
+		return shift(offset), nil
+	}
+
+	var (
+		expr strings.Builder
+		args []interface{}
+	)
+	for {
+		_, offset := at.Zone()
+		_, end := at.ZoneBounds()
+		if end.IsZero() || !end.Before(r.To) {
+			if len(args) == 0 {
+				return shift(offset), nil
+			}
+			fmt.Fprintf(&expr, " ELSE %s END", shift(offset))
+			return "CASE" + expr.String(), args
+		}
+		// datetime() reads the stored time with its offset and returns it in UTC
+		fmt.Fprintf(&expr, " WHEN datetime(%s) < ? THEN %s", column, shift(offset))
+		args = append(args, end.UTC().Format(time.DateTime))
+		at = end
+	}
+}
+
+// Truncate returns the start of the bucket t falls in
+func (r Range) Truncate(t time.Time) time.Time {
+	t = t.In(r.Location)
+	y, m, d := t.Date()
+	switch r.Granularity {
+	case Hour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, r.Location)
+	case Week:
+		// Weeks start on Monday, matching date_trunc('week', ...)
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, r.Location)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, r.Location)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, r.Location)
+	}
+}
+
+// Next returns the start of the bucket following the one starting at t
+func (r Range) Next(t time.Time) time.Time {
+	switch r.Granularity {
+	case Hour:
+		return t.Add(time.Hour)
+	case Week:
+		return t.AddDate(0, 0, 7)
+	case Month:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Buckets lists the start of every bucket in the range so empty ones can be filled
+func (r Range) Buckets() []time.Time {
+	var buckets []time.Time
+	for t := r.Truncate(r.From); t.Before(r.To); t = r.Next(t) {
+		buckets = append(buckets, t)
+	}
+	return buckets
+}
+
+// FromWallClock interprets a timestamp returned by BucketExpr, whose wall
+// clock is already in the range's timezone
+func (r Range) FromWallClock(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, r.Location)
+}
+
+// WallClock scans the result of BucketExpr: Postgres returns a timestamp and
+// SQLite the text of a date or datetime. Either way only the wall clock is
+// meaningful, to be placed in the range's zone with FromWallClock.
+type WallClock struct {
+	time.Time
+}
+
+// Value lets GORM treat the type as a column rather than a relation
+func (w WallClock) Value() (driver.Value, error) {
+	return w.Time, nil
+}
+
+// wallClockLayouts are the text forms SQLite returns, most specific first
+var wallClockLayouts = []string{time.DateTime, time.DateOnly, time.RFC3339Nano}
+
+func (w *WallClock) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		w.Time = time.Time{}
+		return nil
+	case time.Time:
+		w.Time = v
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a wall-clock time", src)
+	}
+
+	for _, layout := range wallClockLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			w.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as a wall-clock time", raw)
+}
+
+// Label formats a bucket start for chart axes
+func (r Range) Label(t time.Time) string {
+	switch r.Granularity {
+	case Hour:
+		return t.Format("2006-01-02T15:00")
+	case Month:
+		return t.Format("2006-01")
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+func (r Range) countBuckets() int {
+	n := 0
+	for t := r.Truncate(r.From); t.Before(r.To) && n <= MaxBuckets; t = r.Next(t) {
+		n++
+	}
+	return n
+}
+
+// ParseBound accepts an RFC 3339 timestamp, or a plain date taken as midnight
+// in loc and reported as dateOnly
+func ParseBound(raw string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if raw == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err = time.ParseInLocation(time.DateOnly, raw, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is not RFC 3339 or YYYY-MM-DD", raw)
+	}
+	return t, true, nil
+}
+
+// EndOfDay is the exclusive end a date-only upper bound stands for, the next
+// midnight, so that the whole day is included
+func EndOfDay(day time.Time) time.Time {
+	return day.AddDate(0, 0, 1)
+}
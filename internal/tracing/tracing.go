@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Sreejit-Sengupto"
+
+// Carrier holds the W3C trace context inside task payloads, since asynq tasks
+// have no headers of their own
+type Carrier map[string]string
+
+var provider *sdktrace.TracerProvider
+
+// InitTracing exports spans over OTLP/HTTP when an endpoint is configured.
+// Without one spans are no-ops, but trace context is still propagated.
+func InitTracing(cfg config.TracingConfig) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Endpoint == "" {
+		slog.Info("tracing endpoint not set, tracing disabled")
+		return
+	}
+
+	ctx := context.Background()
+
+	// The exporter reads the rest of the OTEL_EXPORTER_OTLP_* variables itself
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		log.Fatalf("Failed to create OTLP trace exporter: %v", err)
+		return
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES can add further resource attributes
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create trace resource: %v", err)
+		return
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("tracing enabled")
+}
+
+// ShutdownTracing flushes buffered spans
+func ShutdownTracing() {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		slog.Error("failed to shut down tracer provider", "error", err)
+	}
+}
+
+// Start begins a span as a child of whatever span ctx carries
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail marks the span as failed with err
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		Fail(span, err)
+	}
+	span.End()
+}
+
+// FromRequest continues a trace started by the caller, if the request carries one
+func FromRequest(r *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+}
+
+// Inject captures the trace context of ctx for a task payload
+func Inject(ctx context.Context) Carrier {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return Carrier(carrier)
+}
+
+// Extract continues the trace stored in a task payload
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// Model is the span attribute for the moderation model being called
+func Model(name string) attribute.KeyValue {
+	return attribute.String("model", name)
+}
+
+// ContentID is the span attribute for the content item being processed
+func ContentID(id string) attribute.KeyValue {
+	return attribute.String("content.id", id)
+}
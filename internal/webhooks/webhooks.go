@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Sreejit-Sengupto/internal/models"
+	"github.com/Sreejit-Sengupto/internal/queue/tasks"
+	workerClient "github.com/Sreejit-Sengupto/internal/queue/worker-client"
+	"github.com/Sreejit-Sengupto/internal/repository"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	standardwebhooks "github.com/standard-webhooks/standard-webhooks/libraries/go"
+)
+
+// MaxRetry is how many times a failed delivery is retried before it is marked FAILED
+const MaxRetry = 8
+
+// maxResponseBody caps how much of the receiver's response is kept in the attempt log
+const maxResponseBody = 1024
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Envelope is the body POSTed to subscribers, following the Standard Webhooks layout
+type Envelope struct {
+	Type      models.WebhookEvent `json:"type"`
+	Timestamp time.Time           `json:"timestamp"`
+	Data      interface{}         `json:"data"`
+}
+
+// NewSecret generates a signing secret in the whsec_<base64> format
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Dispatch records a delivery for every active subscription of the tenant
+// listening to the event and pushes it onto the webhook queue. A subscription
+// that fails doesn't stop the others; a delivery that couldn't be enqueued is
+// marked FAILED so it can be redelivered, and the errors are returned joined.
+func Dispatch(ctx context.Context, webhooks repository.WebhookRepository, tenantID string, contentID uuid.UUID, event models.WebhookEvent, data interface{}) error {
+	subscriptions, err := webhooks.ActiveSubscriptions(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhook subscriptions: %v", err)
+	}
+
+	body, err := json.Marshal(Envelope{
+		Type:      event,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("json.Marshal failed: %v", err)
+	}
+
+	var errs []error
+	for _, sub := range subscriptions {
+		if !slices.Contains(sub.Events, event) {
+			continue
+		}
+
+		delivery := models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			ContentId:      contentID,
+			EventType:      event,
+			Payload:        body,
+			Status:         models.DeliveryPending,
+		}
+		if err := webhooks.CreateDelivery(ctx, &delivery); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create webhook delivery for subscription %s: %v", sub.ID, err))
+			continue
+		}
+
+		if err := Enqueue(delivery.ID); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.ID, err))
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = err.Error()
+			if err := webhooks.SaveDelivery(ctx, &delivery); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark webhook delivery %s failed: %v", delivery.ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Enqueue pushes a delivery onto the webhook queue
+func Enqueue(deliveryID uuid.UUID) error {
+	task, err := tasks.NewWebhookDeliveryTask(deliveryID)
+	if err != nil {
+		return fmt.Errorf("tasks.NewWebhookDeliveryTask failed: %v", err)
+	}
+
+	info, err := workerClient.Client.Enqueue(task, asynq.Queue(tasks.QueueWebhook), asynq.MaxRetry(MaxRetry))
+	if err != nil {
+		return fmt.Errorf("workerClient.Client.Enqueue failed: %v", err)
+	}
+	slog.Info("enqueued task", "task_id", info.ID, "queue", info.Queue)
+	return nil
+}
+
+// Send signs the delivery payload with the subscription secret and POSTs it.
+// The delivery ID is used as the webhook-id so receivers can deduplicate retries.
+func Send(ctx context.Context, sub models.WebhookSubscription, delivery models.WebhookDelivery) (int, string, error) {
+	wh, err := standardwebhooks.NewWebhook(sub.Secret)
+	if err != nil {
+		return 0, "", err
+	}
+
+	msgID := delivery.ID.String()
+	ts := time.Now()
+	signature, err := wh.Sign(msgID, ts, delivery.Payload)
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(standardwebhooks.HeaderWebhookID, msgID)
+	req.Header.Set(standardwebhooks.HeaderWebhookTimestamp, fmt.Sprint(ts.Unix()))
+	req.Header.Set(standardwebhooks.HeaderWebhookSignature, signature)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, string(resBody), fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, string(resBody), nil
+}
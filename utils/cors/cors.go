@@ -5,10 +5,19 @@ import (
 )
 
 // Allowed origins for CORS
-var allowedOrigins = map[string]bool{
-	"http://localhost:3000":                           true,
-	"http://localhost:3001":                           true,
-	"https://content-moderation-go-client.vercel.app": true,
+var allowedOrigins = map[string]bool{}
+
+// InitCORS sets the browser origins allowed to call the API
+func InitCORS(origins []string) {
+	allowedOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowedOrigins[origin] = true
+	}
+}
+
+// IsAllowedOrigin reports whether the origin may talk to the API from a browser
+func IsAllowedOrigin(origin string) bool {
+	return allowedOrigins[origin]
 }
 
 // Middleware adds CORS headers to allow requests from the Next.js frontend
@@ -17,11 +26,12 @@ func Middleware(next http.Handler) http.Handler {
 		origin := r.Header.Get("Origin")
 
 		// Check if the origin is allowed
-		if allowedOrigins[origin] {
+		if IsAllowedOrigin(origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-Request-ID, Idempotency-Key, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Idempotent-Replayed, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight OPTIONS request
@@ -3,21 +3,20 @@ package gemini
 import (
 	"context"
 	"fmt"
-	"os"
 
+	"github.com/Sreejit-Sengupto/internal/config"
 	"google.golang.org/genai"
 )
 
 var GeminiClient *genai.Client
 
-func InitGemini() error {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("GEMINI_API_KEY environment variable not set")
+func InitGemini(cfg config.GeminiConfig) error {
+	if cfg.APIKey == "" {
+		return fmt.Errorf("Gemini API key not set")
 	}
 
 	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
-		APIKey: apiKey,
+		APIKey: cfg.APIKey,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create Gemini client: %w", err)
@@ -25,3 +24,12 @@ func InitGemini() error {
 	GeminiClient = client
 	return nil
 }
+
+// Ping checks that the API key works and the model is available
+func Ping(ctx context.Context, model string) error {
+	if GeminiClient == nil {
+		return fmt.Errorf("Gemini client not initialized")
+	}
+	_, err := GeminiClient.Models.Get(ctx, model, nil)
+	return err
+}
@@ -1,18 +1,16 @@
 package imagekit
 
 import (
-	"os"
-
+	"github.com/Sreejit-Sengupto/internal/config"
 	"github.com/imagekit-developer/imagekit-go/v2"
 	"github.com/imagekit-developer/imagekit-go/v2/option"
 )
 
 var ImageKitClient imagekit.Client
 
-func InitImageKit() {
-	prvtKey := os.Getenv("IMAGEKIT_PRIVATE_KEY")
+func InitImageKit(cfg config.ImageKitConfig) {
 	client := imagekit.NewClient(
-		option.WithPrivateKey(prvtKey),
+		option.WithPrivateKey(cfg.PrivateKey),
 	)
 	ImageKitClient = client
 }
@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header carries the tenant a request is made on behalf of
+const Header = "X-Tenant-ID"
+
+// KeyHeader carries the API key a request is authenticated with
+const KeyHeader = "X-API-Key"
+
+// Default is used when a request does not name a tenant
+const Default = "default"
+
+type contextKey struct{}
+
+// Authenticate returns r scoped to the tenant its API key belongs to, which
+// FromRequest then returns whatever the header says
+func Authenticate(r *http.Request, tenantID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), contextKey{}, tenantID))
+}
+
+// Authenticated reports whether r was scoped to a tenant by its API key
+func Authenticated(r *http.Request) bool {
+	_, ok := r.Context().Value(contextKey{}).(string)
+	return ok
+}
+
+// FromRequest returns the tenant of an authenticated request, and otherwise
+// the one it names in the header
+func FromRequest(r *http.Request) string {
+	if t, ok := r.Context().Value(contextKey{}).(string); ok {
+		return t
+	}
+	if t := r.Header.Get(Header); t != "" {
+		return t
+	}
+	return Default
+}